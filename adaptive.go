@@ -0,0 +1,55 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+//bounds for CMapAdaptive's concurrency: it stays between Min and Max, and treats an element that takes more than Tolerance times the fastest one seen as a sign of overload
+type Adaptive struct {
+	Min, Max int
+	Tolerance float64
+}
+
+//the Adaptive settings CMapAdaptive uses for zero fields
+var DefaultAdaptive = Adaptive{1, 64, 2}
+
+//a limiter which adds one to the limit after each limit's worth of completions that are fast and find the consumer keeping up, and halves it when an element is slow or completed results back up, then waits a limit's worth of completions before halving again
+type adaptiveLimit struct {
+	settings Adaptive
+	current int
+	//the fastest completion seen, in nanoseconds, drifting up when the workload gets slower
+	best int64
+	since, cooldown int
+}
+
+func (l *adaptiveLimit) limit() int {return l.current}
+func (l *adaptiveLimit) max() int {return l.settings.Max}
+func (l *adaptiveLimit) timed() bool {return true}
+func (l *adaptiveLimit) completed(ns int64, backlog int) {
+	if l.best == 0 || (ns > 0 && ns < l.best) {l.best = ns}
+	if l.cooldown > 0 {l.cooldown--}
+	l.since++
+	slow := float64(ns) > float64(l.best) * l.settings.Tolerance
+	backedUp := backlog >= l.current
+	switch {
+	case (slow || backedUp) && l.cooldown == 0:
+		l.current /= 2
+		if l.current < l.settings.Min {l.current = l.settings.Min}
+		if slow {l.best = (l.best + ns) / 2}
+		l.cooldown, l.since = l.current, 0
+	case !slow && !backedUp && l.since >= l.current:
+		if l.current < l.settings.Max {l.current++}
+		l.since = 0
+	}
+}
+
+//like CMap, but instead of a fixed number of concurrent instances of f, starts with a.Min and adjusts between a.Min and a.Max as it goes: more while elements complete quickly and the consumer keeps up, fewer when elements slow down or completed results wait on the consumer.  Latency is measured with s's Clock
+func (s Sequence) CMapAdaptive(f func(el El) El, a Adaptive) Sequence {
+	if a.Min <= 0 {a.Min = DefaultAdaptive.Min}
+	if a.Max <= 0 {a.Max = DefaultAdaptive.Max}
+	if a.Max < a.Min {a.Max = a.Min}
+	if a.Tolerance <= 1 {a.Tolerance = DefaultAdaptive.Tolerance}
+	sizePower := uint(0)
+	for 1 << sizePower < a.Max {sizePower++}
+	return s.limitedMap("CMapAdaptive", true, f, &adaptiveLimit{a, a.Min, 0, 0, 0}, sizePower, sizePower + 4).stage("CMapAdaptive", s, "min", a.Min, "max", a.Max, "tolerance", a.Tolerance).inheritMetrics(s)
+}
@@ -0,0 +1,112 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "os"
+
+//returns the result of applying f to its previous value and each element of s after the first, starting with the first element as the previous value, and whether s had any elements
+func (s Sequence) Reduce(f func(acc, el El)El) (El, bool) {
+	var acc El
+	started := false
+	s.Do(func(el El){
+		if started {
+			acc = f(acc, el)
+		} else {
+			acc, started = el, true
+		}
+	})
+	return acc, started
+}
+
+//returns the number of elements of s for which f returns true, counted in one pass without building a filtered sequence
+func (s Sequence) Count(f func(el El)bool) int {
+	count := 0
+	s.Do(func(el El){if f(el) {count++}})
+	return count
+}
+
+//returns the first smallest element of s according to less, and whether s had any elements
+func (s Sequence) Min(less func(a, b El) bool) (El, bool) {
+	return s.Reduce(func(acc, el El)El{
+		if less(el, acc) {return el}
+		return acc
+	})
+}
+
+//returns the first largest element of s according to less, and whether s had any elements
+func (s Sequence) Max(less func(a, b El) bool) (El, bool) {
+	return s.Reduce(func(acc, el El)El{
+		if less(acc, el) {return el}
+		return acc
+	})
+}
+
+//returns the mean of the numbers in s and whether s had any.  A non-numeric element is misuse, reported according to s's Policy; under Lenient, non-numeric elements are skipped, so s with none but non-numeric elements returns (0, false) like an empty s, and AverageErr reports why
+func (s Sequence) Average() (float64, bool) {
+	avg, ok, err := s.AverageErr()
+	s.check(err)
+	return avg, ok
+}
+
+//like Average, but also returns the *SeqError for the first non-numeric element, which does not stop the average under any Policy
+func (s Sequence) AverageErr() (float64, bool, os.Error) {
+	sum, count, index := 0.0, 0, 0
+	var err os.Error
+	s.Do(func(el El){
+		if f, ok := ToFloat64(el); ok {
+			sum += f
+			count++
+		} else if err == nil {
+			err = seqError("Average", "element %d is a %T, not a number", index, el)
+		}
+		index++
+	})
+	if count == 0 {return 0, false, err}
+	return sum / float64(count), true, err
+}
+
+//returns the numbers in s as float64s, copied directly from a Float64Seq or IntSeq.  A non-numeric element is misuse, reported according to s's Policy; under Lenient, non-numeric elements are skipped
+func (s Sequence) Float64s() []float64 {
+	switch numbers := s.base().Seq.(type) {
+	case *Float64Seq: return append([]float64(nil), *numbers...)
+	case *IntSeq:
+		result := make([]float64, len(*numbers))
+		for i, n := range *numbers {result[i] = float64(n)}
+		return result
+	}
+	result := make([]float64, 0, s.quickLen(8))
+	var err *SeqError
+	index := 0
+	s.Do(func(el El){
+		if f, ok := ToFloat64(el); ok {
+			result = append(result, f)
+		} else if err == nil {
+			err = seqError("Float64s", "element %d is a %T, not a number", index, el)
+		}
+		index++
+	})
+	if err != nil {s.check(err)}
+	return result
+}
+
+//converts a numeric element to a float64, returning whether it was numeric
+func ToFloat64(el El) (float64, bool) {
+	switch n := el.(type) {
+	case int: return float64(n), true
+	case int8: return float64(n), true
+	case int16: return float64(n), true
+	case int32: return float64(n), true
+	case int64: return float64(n), true
+	case uint: return float64(n), true
+	case uint8: return float64(n), true
+	case uint16: return float64(n), true
+	case uint32: return float64(n), true
+	case uint64: return float64(n), true
+	case uintptr: return float64(n), true
+	case float32: return float64(n), true
+	case float64: return n, true
+	}
+	return 0, false
+}
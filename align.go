@@ -0,0 +1,106 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "fmt"
+
+//the kind of an Edit
+type EditOp int
+
+const (
+	//A and B are equal
+	Keep EditOp = iota
+	//A is replaced by B
+	Substitute
+	//B is inserted; A is nil
+	Insert
+	//A is deleted; B is nil
+	Delete
+)
+
+func (op EditOp) String() string {
+	switch op {
+	case Keep: return "Keep"
+	case Substitute: return "Substitute"
+	case Insert: return "Insert"
+	case Delete: return "Delete"
+	}
+	return fmt.Sprintf("EditOp(%d)", int(op))
+}
+
+//one step of an alignment between two sequences
+type Edit struct {
+	Op EditOp
+	A, B El
+}
+
+func (e Edit) String() string {
+	switch e.Op {
+	case Keep: return fmt.Sprintf("  %v", e.A)
+	case Substitute: return fmt.Sprintf("~ %v -> %v", e.A, e.B)
+	case Insert: return fmt.Sprintf("+ %v", e.B)
+	case Delete: return fmt.Sprintf("- %v", e.A)
+	}
+	return fmt.Sprintf("%v %v %v", e.Op, e.A, e.B)
+}
+
+//the costs Align uses; nil functions default to reflect.DeepEqual for Equal and a cost of 1 for the others.  Keeping equal elements always costs 0
+type AlignCosts struct {
+	Equal func(a, b El) bool
+	Substitute func(a, b El) int
+	Insert func(b El) int
+	Delete func(a El) int
+}
+
+func (c AlignCosts) withDefaults() AlignCosts {
+	if c.Equal == nil {c.Equal = defaultEqual}
+	if c.Substitute == nil {c.Substitute = func(a, b El) int {return 1}}
+	if c.Insert == nil {c.Insert = func(b El) int {return 1}}
+	if c.Delete == nil {c.Delete = func(a El) int {return 1}}
+	return c
+}
+
+//returns a new SequentialSeq of Edits that transforms s into other at the lowest total cost, and that cost; with the default costs this is the Levenshtein distance
+func (s Sequence) Align(other Sequence, costs AlignCosts) (Sequence, int) {
+	costs = costs.withDefaults()
+	a, b := s.ToSlice(), other.ToSlice()
+	//dist[i][j] is the cost of aligning a[:i] with b[:j]
+	dist := make([][]int, len(a) + 1)
+	for i := range dist {
+		dist[i] = make([]int, len(b) + 1)
+		if i > 0 {dist[i][0] = dist[i - 1][0] + costs.Delete(a[i - 1])}
+	}
+	for j := 1; j <= len(b); j++ {dist[0][j] = dist[0][j - 1] + costs.Insert(b[j - 1])}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			best := dist[i - 1][j - 1]
+			if !costs.Equal(a[i - 1], b[j - 1]) {best += costs.Substitute(a[i - 1], b[j - 1])}
+			if d := dist[i - 1][j] + costs.Delete(a[i - 1]); d < best {best = d}
+			if d := dist[i][j - 1] + costs.Insert(b[j - 1]); d < best {best = d}
+			dist[i][j] = best
+		}
+	}
+	edits := make([]interface{}, 0, len(a) + len(b))
+	for i, j := len(a), len(b); i > 0 || j > 0; {
+		switch {
+		case i > 0 && j > 0 && costs.Equal(a[i - 1], b[j - 1]) && dist[i][j] == dist[i - 1][j - 1]:
+			edits = append(edits, Edit{Keep, a[i - 1], b[j - 1]})
+			i--
+			j--
+		case i > 0 && j > 0 && dist[i][j] == dist[i - 1][j - 1] + costs.Substitute(a[i - 1], b[j - 1]) && !costs.Equal(a[i - 1], b[j - 1]):
+			edits = append(edits, Edit{Substitute, a[i - 1], b[j - 1]})
+			i--
+			j--
+		case i > 0 && dist[i][j] == dist[i - 1][j] + costs.Delete(a[i - 1]):
+			edits = append(edits, Edit{Delete, a[i - 1], nil})
+			i--
+		default:
+			edits = append(edits, Edit{Insert, nil, b[j - 1]})
+			j--
+		}
+	}
+	for l, r := 0, len(edits) - 1; l < r; l, r = l + 1, r - 1 {edits[l], edits[r] = edits[r], edits[l]}
+	return Sequence{(*SequentialSeq)(&edits)}, dist[len(a)][len(b)]
+}
@@ -0,0 +1,107 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "fmt"
+import "os"
+import "sync"
+
+//what Buffered does with an element that arrives when its buffer is full
+type Overflow int
+
+const (
+	//stop reading the producer until the consumer makes room, which is what an unbuffered sequence does
+	Block Overflow = iota
+	//drop the oldest buffered element to make room
+	DropOldest
+	//drop the arriving element
+	DropNewest
+	//end the sequence with a *SeqError, available from its Err method
+	Fail
+)
+
+func (o Overflow) String() string {
+	switch o {
+	case Block: return "Block"
+	case DropOldest: return "DropOldest"
+	case DropNewest: return "DropNewest"
+	case Fail: return "Fail"
+	}
+	return fmt.Sprintf("Overflow(%d)", int(o))
+}
+
+//a thread-safe count of dropped elements
+type dropCounter struct {
+	lock sync.Mutex
+	n int64
+}
+
+func (d *dropCounter) add() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.n++
+}
+
+func (d *dropCounter) Dropped() int64 {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.n
+}
+
+//a sequence which counts the elements its buffer dropped
+type droppingSeq struct {
+	Seq
+	*dropCounter
+}
+
+func (s *droppingSeq) unwrap() Seq {return s.Seq}
+func (s *droppingSeq) Rest() Sequence {return Sequence{&droppingSeq{s.Seq.Rest().Seq, s.dropCounter}}}
+
+//returns a new ConcurrentSeq with the elements of s, read from s as fast as it produces them into a buffer of size elements, so a slow consumer does not hold the producer back until the buffer is full; then overflow decides what happens.  Buffered(Block, n) is a plain buffer of n, and the sequence's Dropped method counts the elements DropOldest and DropNewest have discarded, over all traversals
+func (s Sequence) Buffered(overflow Overflow, size int) Sequence {
+	if size < 1 {size = 1}
+	drops := &dropCounter{}
+	result := GenErr(func(output SeqChan) os.Error {
+		input := s.channel()
+		defer close(input)
+		queue := make([]interface{}, 0, size)
+		inputClosed := false
+		for !inputClosed || len(queue) > 0 {
+			ic, oc := input, output
+			var first El
+			if len(queue) == 0 {
+				oc = nil
+			} else {
+				first = queue[0]
+			}
+			if inputClosed || (overflow == Block && len(queue) >= size) {ic = nil}
+			select {
+			case oc <- first:
+				if closed(oc) {return nil}
+				queue = queue[1:]
+			case el := <- ic:
+				switch {
+				case closed(ic): inputClosed = true
+				case len(queue) < size: queue = append(queue, el)
+				case overflow == DropOldest:
+					queue = append(queue[1:], el)
+					drops.add()
+				case overflow == DropNewest: drops.add()
+				default: return seqError("Buffered", "buffer of %d overflowed", size)
+				}
+			}
+		}
+		return nil
+	}).stage("Buffered", s, "overflow", overflow, "size", size)
+	return Sequence{&droppingSeq{result.Seq, drops}}
+}
+
+//returns the number of elements a Buffered sequence has dropped, or 0 if s is not one
+func (s Sequence) Dropped() int64 {
+	for _, layer := range s.layers() {
+		if d, ok := layer.(*droppingSeq); ok {return d.Dropped()}
+	}
+	return 0
+}
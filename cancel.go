@@ -0,0 +1,78 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "sync"
+
+//a concurrent sequence whose generator is told, through a done channel, when its consumer stops
+type cancelSeq struct {
+	f func(done <-chan bool, c SeqChan)
+	parent <-chan bool
+	skip int
+}
+
+//returns a new ConcurrentSeq of the items f writes to the channel, like Gen, except that f also gets a done channel, which is closed as soon as the traversal stops, whether because s ran out, Find found its element, or a consumer gave up.  A generator blocked reading a file or waiting on a timer can select on done to give up promptly instead of noticing closed(c) only after its next send.  If parentOpt is given, closing parentOpt[0] closes done as well, so one channel can cancel a whole pipeline of generators
+func GenDone(f func(done <-chan bool, c SeqChan), parentOpt... <-chan bool) Sequence {
+	var parent <-chan bool
+	if len(parentOpt) > 0 {parent = parentOpt[0]}
+	return Sequence{&cancelSeq{f, parent, 0}}.sourceStage("GenDone")
+}
+
+//starts the generator, returning its channel and a function which stops it
+func (s *cancelSeq) start() (SeqChan, func()) {
+	c := make(SeqChan)
+	done := make(chan bool)
+	finished := make(chan bool)
+	var once sync.Once
+	cancel := func() {once.Do(func(){close(done)})}
+	go func() {
+		defer close(c)
+		s.f(done, c)
+	}()
+	if s.parent != nil {
+		go func() {
+			select {
+			case <- s.parent: cancel()
+			case <- finished:
+			}
+		}()
+	}
+	return c, func() {
+		cancel()
+		close(finished)
+		close(c)
+	}
+}
+
+//cancelSeqs are concurrent; return true
+func (s *cancelSeq) IsConcurrent() bool {return true}
+
+//returns the first item in a sequence for which f returns true or nil if none is found, closing the generator's done channel when it returns
+func (s *cancelSeq) Find(f func(el El)bool) El {
+	c, stop := s.start()
+	defer stop()
+	skip := s.skip
+	for el := <- c; !closed(c); el = <- c {
+		if skip > 0 {
+			skip--
+		} else if f(el) {
+			return el
+		}
+	}
+	return nil
+}
+
+//returns a new cancelSeq consisting of all of the elements of s except for the first one
+func (s *cancelSeq) Rest() Sequence {return Sequence{&cancelSeq{s.f, s.parent, s.skip + 1}}}
+
+//returns the length of s
+func (s *cancelSeq) Len() int {
+	count := 0
+	s.Find(func(el El)bool{
+		count++
+		return false
+	})
+	return count
+}
@@ -0,0 +1,96 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "sync"
+import "time"
+
+//the source of time for time-based operators; times and durations are in nanoseconds
+type Clock interface {
+	Now() int64
+	//returns a channel which receives the time once ns nanoseconds have passed
+	After(ns int64) <-chan int64
+}
+
+type realClock struct{}
+
+func (c realClock) Now() int64 {return time.Nanoseconds()}
+func (c realClock) After(ns int64) <-chan int64 {return time.After(ns)}
+
+//the Clock backed by the system time
+var RealClock Clock = realClock{}
+
+//the clock for sequences that have not been given one with WithClock
+var DefaultClock = RealClock
+
+type manualWaiter struct {
+	at int64
+	c chan int64
+}
+
+//a Clock which only moves when told to, so tests can run time-based operators deterministically
+type ManualClock struct {
+	lock sync.Mutex
+	now int64
+	waiters []manualWaiter
+}
+
+//returns a new ManualClock whose time is now
+func NewManualClock(now int64) *ManualClock {return &ManualClock{now: now}}
+
+//returns the clock's time
+func (m *ManualClock) Now() int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.now
+}
+
+//returns a channel which receives the time once the clock has been advanced by ns
+func (m *ManualClock) After(ns int64) <-chan int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	c := make(chan int64, 1)
+	if ns <= 0 {
+		c <- m.now
+	} else {
+		m.waiters = append(m.waiters, manualWaiter{m.now + ns, c})
+	}
+	return c
+}
+
+//moves the clock forward by ns, firing any After channels that come due
+func (m *ManualClock) Advance(ns int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.now += ns
+	pending := m.waiters[:0]
+	for _, w := range m.waiters {
+		if w.at <= m.now {
+			w.c <- m.now
+		} else {
+			pending = append(pending, w)
+		}
+	}
+	m.waiters = pending
+}
+
+type clockSeq struct {
+	Seq
+	clock Clock
+}
+
+func (s *clockSeq) unwrap() Seq {return s.Seq}
+func (s *clockSeq) Rest() Sequence {return s.Seq.Rest().WithClock(s.clock)}
+
+//returns a sequence with the same elements as s whose time-based operators, and those of the sequences they produce, use clock
+func (s Sequence) WithClock(clock Clock) Sequence {return Sequence{&clockSeq{s.Seq, clock}}}
+
+//returns the clock s's time-based operators use
+func (s Sequence) Clock() Clock {
+	for _, layer := range s.layers() {
+		if c, ok := layer.(*clockSeq); ok {return c.clock}
+	}
+	return DefaultClock
+}
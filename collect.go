@@ -0,0 +1,98 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "os"
+
+//returns a new map from key(el) to value(el) for each element of s; later elements replace earlier ones with the same key
+func (s Sequence) ToMap(key func(el El) interface{}, value func(el El) interface{}) map[interface{}]interface{} {
+	result := make(map[interface{}]interface{}, s.quickLen(8))
+	s.Do(func(el El){result[key(el)] = value(el)})
+	return result
+}
+
+//returns a new map from key(el) to value(el) for each element of s, or a *SeqError if two elements have the same key
+func (s Sequence) ToMapUnique(key func(el El) interface{}, value func(el El) interface{}) (map[interface{}]interface{}, os.Error) {
+	result := make(map[interface{}]interface{}, s.quickLen(8))
+	var err os.Error
+	s.Find(func(el El)bool{
+		k := key(el)
+		if _, dup := result[k]; dup {
+			err = seqError("ToMapUnique", "duplicate key %v", k)
+			return true
+		}
+		result[k] = value(el)
+		return false
+	})
+	return result, err
+}
+
+//returns a new map from each distinct element of s to the number of times it occurs
+func (s Sequence) Frequencies() map[interface{}]int {return s.FrequenciesBy(func(el El) interface{} {return el})}
+
+//returns a new map from each distinct key(el) of the elements of s to the number of elements with that key
+func (s Sequence) FrequenciesBy(key func(el El) interface{}) map[interface{}]int {
+	result := map[interface{}]int{}
+	s.Do(func(el El){result[key(el)]++})
+	return result
+}
+
+//an element with its key, sent to a ReduceByKey shard
+type keyedEl struct {
+	key interface{}
+	el El
+}
+
+//returns a new SequentialSeq of [key, result] sequences, one for each distinct key(el) of the elements of s, in order of the keys' first appearance.  Each result is the Reduce of the elements with that key: the first one, with reduce applied to it and each later one in turn.  s is read once, without storing its elements.  If shardsOpt[0] is more than 1, the keys are dealt out to that many goroutines, which reduce their keys' elements concurrently; each key's elements are still reduced in order
+func (s Sequence) ReduceByKey(key func(el El) interface{}, reduce func(acc, el El) El, shardsOpt... int) Sequence {
+	shards := 1
+	if len(shardsOpt) > 0 && shardsOpt[0] > 1 {shards = shardsOpt[0]}
+	accs := make([]map[interface{}]interface{}, shards)
+	for i := range accs {accs[i] = map[interface{}]interface{}{}}
+	reduceInto := func(acc map[interface{}]interface{}, k interface{}, el El) {
+		if prev, seen := acc[k]; seen {
+			acc[k] = reduce(prev, el)
+		} else {
+			acc[k] = el
+		}
+	}
+	order := []interface{}{}
+	shardOf := map[interface{}]int{}
+	if shards == 1 {
+		s.Do(func(el El){
+			k := key(el)
+			if _, seen := shardOf[k]; !seen {
+				shardOf[k] = 0
+				order = append(order, k)
+			}
+			reduceInto(accs[0], k, el)
+		})
+	} else {
+		inputs := make([]chan keyedEl, shards)
+		done := make(chan bool)
+		for i := range inputs {
+			inputs[i] = make(chan keyedEl, 64)
+			go func(input chan keyedEl, acc map[interface{}]interface{}) {
+				for item := range input {reduceInto(acc, item.key, item.el)}
+				done <- true
+			}(inputs[i], accs[i])
+		}
+		s.Do(func(el El){
+			k := key(el)
+			shard, seen := shardOf[k]
+			if !seen {
+				shard = len(order) % shards
+				shardOf[k] = shard
+				order = append(order, k)
+			}
+			inputs[shard] <- keyedEl{k, el}
+		})
+		for _, input := range inputs {close(input)}
+		for i := 0; i < shards; i++ {<- done}
+	}
+	result := make([]interface{}, len(order))
+	for i, k := range order {result[i] = From(k, accs[shardOf[k]][k])}
+	return Sequence{(*SequentialSeq)(&result)}
+}
@@ -0,0 +1,255 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "os"
+
+//calls f with each k-element combination of items, as a new slice, in lexicographic order of item positions, until f returns false; returns whether f always returned true
+func eachCombination(items []interface{}, k int, f func(combination []interface{}) bool) bool {
+	if k < 0 || k > len(items) {return true}
+	//indexes holds the positions of the current combination's items, in increasing order
+	indexes := make([]int, k)
+	for i := range indexes {indexes[i] = i}
+	for {
+		combination := make([]interface{}, k)
+		for i, index := range indexes {combination[i] = items[index]}
+		if !f(combination) {return false}
+		//advance the rightmost index that can move, and reset the ones after it
+		i := k - 1
+		for i >= 0 && indexes[i] == len(items) - k + i {i--}
+		if i < 0 {return true}
+		indexes[i]++
+		for j := i + 1; j < k; j++ {indexes[j] = indexes[j - 1] + 1}
+	}
+	return true
+}
+
+//returns a new ConcurrentSeq of every subset of the elements of s, as SequentialSeqs, generated lazily: the empty subset first, then the subsets of each larger size in turn, each size in lexicographic order of element positions
+func (s Sequence) Subsets() Sequence {
+	return Gen(func(c SeqChan){
+		items := s.ToSlice()
+		for k := 0; k <= len(items); k++ {
+			if !eachCombination(items, k, emitCombination(c)) {return}
+		}
+	}).stage("Subsets", s)
+}
+
+//returns a new ConcurrentSeq of every subset of exactly k of the elements of s, as SequentialSeqs, generated lazily in lexicographic order of element positions
+func (s Sequence) SubsetsOfSize(k int) Sequence {
+	return Gen(func(c SeqChan){eachCombination(s.ToSlice(), k, emitCombination(c))}).stage("SubsetsOfSize", s, "k", k)
+}
+
+//returns a function that sends combinations to c, stopping if the consumer has closed it
+func emitCombination(c SeqChan) func(combination []interface{}) bool {
+	return func(combination []interface{}) bool {
+		c <- Sequence{(*SequentialSeq)(&combination)}
+		return !closed(c)
+	}
+}
+
+//returns the positions of the first combination of up to k of n items.
+//Each combination comes after all of its extensions, which are in increasing order of their added positions, so the combinations of [a, b, c] up to size 2 are [a, b], [a, c], [a], [b, c], [b], [c], []
+func firstCombinationUpTo(n, k int) []int {
+	if k > n {k = n}
+	return descendCombination(make([]int, 0, k), n, k)
+}
+
+//extends indexes with the positions of its first extension of up to k of n items
+func descendCombination(indexes []int, n, k int) []int {
+	for len(indexes) < k {
+		next := 0
+		if len(indexes) > 0 {next = indexes[len(indexes) - 1] + 1}
+		if next >= n {break}
+		indexes = append(indexes, next)
+	}
+	return indexes
+}
+
+//advances indexes, in place, to the positions of the combination after it, in firstCombinationUpTo's order, and returns them and whether there was one
+func nextCombinationUpTo(indexes []int, n, k int) ([]int, bool) {
+	if k > n {k = n}
+	if len(indexes) == 0 {return indexes, false}
+	if last := len(indexes) - 1; indexes[last] + 1 < n {
+		//move to the next sibling and down to its first extension
+		indexes[last]++
+		return descendCombination(indexes, n, k), true
+	}
+	//the parent's extensions are all done, so the parent is next
+	return indexes[:len(indexes) - 1], true
+}
+
+//a lazy sequence of the combinations of items, generated during each traversal rather than stored; indexes holds the positions of its first combination, or is nil once they have all been skipped, and taken counts the combinations skipped before it
+type combinationSeq struct {
+	items []interface{}
+	max int
+	indexes []int
+	taken int
+}
+
+//combinationSeqs are not concurrent; return false
+func (s *combinationSeq) IsConcurrent() bool {return false}
+
+//returns the first combination for which f returns true or nil if none is found
+func (s *combinationSeq) Find(f func(el El)bool) El {
+	if s.indexes == nil {return nil}
+	indexes := s.copyIndexes()
+	for more := true; more; indexes, more = nextCombinationUpTo(indexes, len(s.items), s.max) {
+		combination := make([]interface{}, len(indexes))
+		for i, index := range indexes {combination[i] = s.items[index]}
+		if el := (Sequence{(*SequentialSeq)(&combination)}); f(el) {return el}
+	}
+	return nil
+}
+
+//returns a new copy of s's indexes with room to extend them, so advancing it leaves s alone
+func (s *combinationSeq) copyIndexes() []int {
+	indexes := make([]int, len(s.indexes), cap(s.indexes))
+	copy(indexes, s.indexes)
+	return indexes
+}
+
+//returns a new combinationSeq which starts at the combination after the first combination of s, so walking a sequence by Rest takes one step per combination
+func (s *combinationSeq) Rest() Sequence {
+	if s.indexes == nil {return Sequence{s}}
+	indexes, more := nextCombinationUpTo(s.copyIndexes(), len(s.items), s.max)
+	if !more {indexes = nil}
+	return Sequence{&combinationSeq{s.items, s.max, indexes, s.taken + 1}}
+}
+
+//returns the number of combinations, computed without generating them
+func (s *combinationSeq) Len() int {
+	total, ways := 0, 1
+	for size := 0; size <= s.max && size <= len(s.items); size++ {
+		total += ways
+		ways = ways * (len(s.items) - size) / (size + 1)
+	}
+	if total < s.taken {return 0}
+	return total - s.taken
+}
+
+func (s *combinationSeq) Describe() Description {
+	return Description{"Combinations", map[string]interface{}{"items": len(s.items), "max": s.max}, nil}
+}
+
+//returns a new lazy sequence consisting of all possible combinations of the elements of s of size number or smaller, as SequentialSeqs.  The combinations are generated one at a time each time the sequence is traversed, and each combination comes after all of its extensions: the combinations of [a, b, c] up to size 2 are [a, b], [a, c], [a], [b, c], [b], [c], []
+func (s Sequence) Combinations(number int) Sequence {
+	if number < 0 {number = 0}
+	items := s.ToSlice()
+	return Sequence{&combinationSeq{items, number, firstCombinationUpTo(len(items), number), 0}}
+}
+
+//a lazy sequence of the tuples of a cartesian product, generated odometer-style during each traversal rather than stored
+type productSeq struct {
+	components [][]interface{}
+	skip int
+}
+
+//sets indexes to the digits of the tuple at position n of the product, with the last component varying fastest
+func (s *productSeq) odometer(n int, indexes []int) {
+	for i := len(s.components) - 1; i >= 0; i-- {
+		size := len(s.components[i])
+		indexes[i] = n % size
+		n /= size
+	}
+}
+
+//returns a new slice holding the tuple indexes selects
+func (s *productSeq) tuple(indexes []int) []interface{} {
+	tuple := make([]interface{}, len(indexes))
+	for i, index := range indexes {tuple[i] = s.components[i][index]}
+	return tuple
+}
+
+//productSeqs are not concurrent; return false
+func (s *productSeq) IsConcurrent() bool {return false}
+
+//returns the first tuple for which f returns true or nil if none is found
+func (s *productSeq) Find(f func(el El)bool) El {
+	total := s.Len()
+	if total == 0 {return nil}
+	indexes := make([]int, len(s.components))
+	s.odometer(s.skip, indexes)
+	for n := 0; n < total; n++ {
+		tuple := s.tuple(indexes)
+		if el := (Sequence{(*SequentialSeq)(&tuple)}); f(el) {return el}
+		//turn the odometer: advance the last digit, carrying into the ones before it
+		for i := len(indexes) - 1; i >= 0; i-- {
+			indexes[i]++
+			if indexes[i] < len(s.components[i]) {break}
+			indexes[i] = 0
+		}
+	}
+	return nil
+}
+
+//returns a new productSeq which skips the first tuple of s
+func (s *productSeq) Rest() Sequence {return Sequence{&productSeq{s.components, s.skip + 1}}}
+
+//returns the number of tuples, computed without generating them
+func (s *productSeq) Len() int {
+	total := 1
+	for _, component := range s.components {total *= len(component)}
+	if total < s.skip {return 0}
+	return total - s.skip
+}
+
+func (s *productSeq) Describe() Description {
+	return Description{"LazyProduct", map[string]interface{}{"components": len(s.components)}, nil}
+}
+
+//returns the product of the elements of sequences, where each element is a sequence, like Product, but as a lazy sequence which generates its tuples one at a time each time it is traversed, so filtering the product does not need it all in memory.  The tuples are SequentialSeqs in the same order as Product's.  If an element is not a sequence, sequences' Policy decides whether to panic or to return an empty sequence
+func (sequences Sequence) LazyProduct() Sequence {
+	if product := sequences.productSeq("LazyProduct"); product != nil {return Sequence{product}}
+	return From()
+}
+
+//returns a productSeq over the elements of sequences or, after applying sequences' Policy to the error, nil if an element is not a sequence
+func (sequences Sequence) productSeq(op string) *productSeq {
+	components := make([][]interface{}, 0, sequences.quickLen(8))
+	var err os.Error
+	sequences.While(func(el El)bool{
+		var component Sequence
+		if component, err = productComponent(op, len(components), el); err != nil {return false}
+		components = append(components, component.ToSlice())
+		return true
+	})
+	if err != nil {
+		sequences.check(err)
+		return nil
+	}
+	return &productSeq{components, 0}
+}
+
+//returns the number of tuples in the product of the elements of sequences, computed from the component lengths without generating any tuples
+func (sequences Sequence) ProductLen() int {
+	total, index := 1, 0
+	var err os.Error
+	sequences.While(func(el El)bool{
+		var component Sequence
+		if component, err = productComponent("ProductLen", index, el); err != nil {return false}
+		total *= component.Len()
+		index++
+		return true
+	})
+	if err != nil {
+		sequences.check(err)
+		return 0
+	}
+	return total
+}
+
+//returns tuple i of the product of the elements of sequences, in Product's order, computed directly from the component lengths without enumerating the tuples before it; handy for randomly sampling large product spaces.  If i is out of range, sequences' Policy decides whether to panic or to return an empty sequence
+func (sequences Sequence) ProductNth(i int) Sequence {
+	product := sequences.productSeq("ProductNth")
+	if product == nil {return From()}
+	if i < 0 || i >= product.Len() {
+		sequences.check(seqError("ProductNth", "index %d out of range for a product of %d tuples", i, product.Len()))
+		return From()
+	}
+	indexes := make([]int, len(product.components))
+	product.odometer(i, indexes)
+	tuple := product.tuple(indexes)
+	return Sequence{(*SequentialSeq)(&tuple)}
+}
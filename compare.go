@@ -0,0 +1,69 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "fmt"
+import "hash"
+
+//returns an equality function that compares sequences element-wise, recursing into nested sequences, and compares everything else with eq.  Sequences compare equal regardless of whether they are sequential or concurrent, so the result also works as the eqOpt of LCS, Align, and friends
+func EqualBy(eq func(a, b El) bool) func(a, b El) bool {
+	var equal func(a, b El) bool
+	equal = func(a, b El) bool {
+		sa, aIsSeq := a.(Sequence)
+		sb, bIsSeq := b.(Sequence)
+		if !aIsSeq || !bIsSeq {return !aIsSeq && !bIsSeq && eq(a, b)}
+		as, bs := sa.ToSlice(), sb.ToSlice()
+		if len(as) != len(bs) {return false}
+		for i := range as {
+			if !equal(as[i], bs[i]) {return false}
+		}
+		return true
+	}
+	return equal
+}
+
+//returns whether a and b have equal elements in the same order, recursing into nested sequences, whether each is sequential or concurrent
+func SeqEqual(a, b Sequence) bool {return EqualBy(defaultEqual)(a, b)}
+
+//compares a and b lexicographically, returning a negative number, zero, or a positive number as a sorts before, with, or after b.  Elements that are both sequences are compared recursively and everything else is compared with cmp; when one sequence is a prefix of the other, the shorter one sorts first.  This gives a deterministic order for the tuples of Product or Combinations
+func SeqCompare(a, b Sequence, cmp func(a, b El) int) int {
+	as, bs := a.ToSlice(), b.ToSlice()
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		var result int
+		sa, aIsSeq := as[i].(Sequence)
+		sb, bIsSeq := bs[i].(Sequence)
+		if aIsSeq && bIsSeq {
+			result = SeqCompare(sa, sb, cmp)
+		} else {
+			result = cmp(as[i], bs[i])
+		}
+		if result != 0 {return result}
+	}
+	return len(as) - len(bs)
+}
+
+//writes the type and value of el to h; the element hashing used when none is given
+func defaultHashEl(el El, h hash.Hash64) {fmt.Fprintf(h, "%T:%#v", el, el)}
+
+//returns a digest of the contents of s, so sequences can be keyed or deduplicated by content.  Nested sequences are hashed recursively, with their boundaries marked, so From(1, From(2)) and From(From(1), 2) differ; everything else is hashed with hashEl, which defaults to hashing the element's type and value when nil.  h is reset first, so the digest covers only s
+func HashSeq(s Sequence, h hash.Hash64, hashEl func(El, hash.Hash64)) uint64 {
+	if hashEl == nil {hashEl = defaultHashEl}
+	h.Reset()
+	hashInto(s, h, hashEl)
+	return h.Sum64()
+}
+
+func hashInto(s Sequence, h hash.Hash64, hashEl func(El, hash.Hash64)) {
+	h.Write([]byte{'('})
+	s.Do(func(el El){
+		if nested, isSeq := el.(Sequence); isSeq {
+			hashInto(nested, h, hashEl)
+		} else {
+			hashEl(el, h)
+		}
+		h.Write([]byte{0})
+	})
+	h.Write([]byte{')'})
+}
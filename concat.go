@@ -0,0 +1,100 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "sync"
+
+//the parts of one or more concatSeqs, which share the slice so appending to the longest of them does not copy it
+type concatParts struct {
+	lock sync.Mutex
+	parts []Sequence
+}
+
+//a view of the concatenation of sequential sequences, which refers to them rather than copying their elements.  Its parts are shared's parts from start up to n, except that head, if it is set, stands in for the part at start, so Rest can drop an element without copying the parts
+type concatSeq struct {
+	shared *concatParts
+	start, n int
+	head *Sequence
+}
+
+//returns a new sequence of the elements of s1 followed by those of s2, which must not be concurrent, without copying either; if s1 is already a concatenation, s2 is added to its parts, which takes constant time when s1 is the latest concatenation made from them
+func concat(s1, s2 Sequence) Sequence {
+	if c, ok := s1.base().Seq.(*concatSeq); ok {return c.append(s2)}
+	return Sequence{&concatSeq{&concatParts{parts: []Sequence{s1, s2}}, 0, 2, nil}}
+}
+
+func (s *concatSeq) append(s2 Sequence) Sequence {
+	s.shared.lock.Lock()
+	defer s.shared.lock.Unlock()
+	if len(s.shared.parts) == s.n {
+		s.shared.parts = append(s.shared.parts, s2)
+		return Sequence{&concatSeq{s.shared, s.start, s.n + 1, s.head}}
+	}
+	parts := make([]Sequence, s.n - s.start, s.n - s.start + 1)
+	copy(parts, s.shared.parts[s.start:s.n])
+	if s.head != nil {parts[0] = *s.head}
+	return Sequence{&concatSeq{&concatParts{parts: append(parts, s2)}, 0, len(parts) + 1, nil}}
+}
+
+//calls f with each of s's parts in order until f returns false
+func (s *concatSeq) eachPart(f func(part Sequence) bool) {
+	s.shared.lock.Lock()
+	parts := s.shared.parts[s.start:s.n]
+	s.shared.lock.Unlock()
+	for i, part := range parts {
+		if i == 0 && s.head != nil {part = *s.head}
+		if !f(part) {return}
+	}
+}
+
+//concatSeqs are not concurrent; return false
+func (s *concatSeq) IsConcurrent() bool {return false}
+
+//returns the first item in a sequence for which f returns true or nil if none is found
+func (s *concatSeq) Find(f func(el El)bool) El {
+	var result El
+	found := false
+	s.eachPart(func(part Sequence) bool {
+		result = part.Find(func(el El)bool{
+			found = f(el)
+			return found
+		})
+		return !found
+	})
+	if !found {return nil}
+	return result
+}
+
+//returns a new concatSeq consisting of all of the elements of s except for the first one, sharing s's parts; this skips any empty parts at the front and then takes the Rest of the first part that has elements
+func (s *concatSeq) Rest() Sequence {
+	start, head := s.start, s.head
+	for ; start < s.n; start, head = start + 1, nil {
+		var first Sequence
+		if head != nil {
+			first = *head
+		} else {
+			s.shared.lock.Lock()
+			first = s.shared.parts[start]
+			s.shared.lock.Unlock()
+		}
+		if !first.IsEmpty() {
+			rest := first.Rest()
+			return Sequence{&concatSeq{s.shared, start, s.n, &rest}}
+		}
+	}
+	return From()
+}
+
+//returns the length of s, the sum of its parts' lengths
+func (s *concatSeq) Len() int {
+	total := 0
+	s.eachPart(func(part Sequence) bool {
+		total += part.Len()
+		return true
+	})
+	return total
+}
+
+func (s *concatSeq) Describe() Description {return Description{"Concat", map[string]interface{}{"parts": s.n - s.start}, nil}}
@@ -0,0 +1,60 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+//a persistent linked list: adding to the front is O(1) and shares the rest of the list rather than copying it.  The nil *ConsSeq is the empty list
+type ConsSeq struct {
+	head El
+	tail *ConsSeq
+	length int
+}
+
+//returns a new ConsSeq consisting of els
+func List(els... interface{}) Sequence {
+	var list *ConsSeq
+	for i := len(els) - 1; i >= 0; i-- {list = list.Cons(els[i])}
+	return Sequence{list}
+}
+
+//returns a new ConsSeq with el in front of s, sharing s
+func (s *ConsSeq) Cons(el El) *ConsSeq {return &ConsSeq{el, s, s.Len() + 1}}
+
+//ConsSeqs are not concurrent; return false
+func (s *ConsSeq) IsConcurrent() bool {return false}
+
+//returns the first item in a sequence for which f returns true or nil if none is found
+func (s *ConsSeq) Find(f func(el El)bool) El {
+	for cell := s; cell != nil; cell = cell.tail {
+		if f(cell.head) {return cell.head}
+	}
+	return nil
+}
+
+//returns the ConsSeq of all of the elements of s except for the first one, which shares s's cells
+func (s *ConsSeq) Rest() Sequence {
+	if s == nil {return Sequence{s}}
+	return Sequence{s.tail}
+}
+
+//returns the length of s
+func (s *ConsSeq) Len() int {
+	if s == nil {return 0}
+	return s.length
+}
+
+func (s *ConsSeq) Describe() Description {return Description{"ConsSeq", map[string]interface{}{"len": s.Len()}, nil}}
+
+//returns a new sequence with el in front of s; for a ConsSeq this is O(1) and shares s
+func (s Sequence) Cons(el El) Sequence {
+	if list, ok := s.base().Seq.(*ConsSeq); ok {return Sequence{list.Cons(el)}}
+	return s.Prepend(From(el))
+}
+
+//returns a new ConsSeq consisting of the elements of s2 followed by list, sharing list
+func (list *ConsSeq) prependAll(s2 Sequence) Sequence {
+	els := s2.ToSlice()
+	for i := len(els) - 1; i >= 0; i-- {list = list.Cons(els[i])}
+	return Sequence{list}
+}
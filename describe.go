@@ -0,0 +1,94 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "fmt"
+import "bytes"
+import "reflect"
+import "sort"
+
+//a description of a sequence as data: the kind of stage that produced it, the parameters it was given, and the description of the sequence it reads from (nil for sources)
+type Description struct {
+	Stage string
+	Params map[string]interface{}
+	Source *Description
+}
+
+//Seq implementations can implement Describer to describe themselves
+type Describer interface {
+	Describe() Description
+}
+
+//returns a description of s, suitable for logging or for walking the stages of a pipeline
+func (s Sequence) Describe() Description {
+	switch seq := s.Seq.(type) {
+	case Describer: return seq.Describe()
+	case seqWrapper: return Sequence{seq.unwrap()}.Describe()
+	case *SequentialSeq: return Description{"SequentialSeq", map[string]interface{}{"len": seq.Len()}, nil}
+	case ConcurrentSeq: return Description{"ConcurrentSeq", nil, nil}
+	}
+	return Description{reflect.Typeof(s.Seq).String(), nil, nil}
+}
+
+//returns the stages of d, starting with d and ending with its original source
+func (d Description) Stages() []Description {
+	result := []Description{}
+	for cur := &d; cur != nil; cur = cur.Source {
+		result = append(result, *cur)
+	}
+	return result
+}
+
+//returns a one-line rendering of d like "CMap(sizePower=6) <- SequentialSeq(len=10)"
+func (d Description) String() string {
+	buf := bytes.NewBuffer(make([]byte, 0, 64))
+	for i, stage := range d.Stages() {
+		if i > 0 {buf.WriteString(" <- ")}
+		buf.WriteString(stage.Stage)
+		if len(stage.Params) > 0 {
+			keys := make([]string, 0, len(stage.Params))
+			for k := range stage.Params {keys = append(keys, k)}
+			sort.SortStrings(keys)
+			buf.WriteString("(")
+			for j, k := range keys {
+				if j > 0 {buf.WriteString(", ")}
+				fmt.Fprintf(buf, "%s=%v", k, stage.Params[k])
+			}
+			buf.WriteString(")")
+		}
+	}
+	return buf.String()
+}
+
+//a Seq annotated with the description of the stage that produced it
+type describedSeq struct {
+	Seq
+	desc Description
+}
+
+func (s *describedSeq) unwrap() Seq {return s.Seq}
+func (s *describedSeq) Describe() Description {return s.desc}
+
+//returns s annotated as the output of stage applied to source, with source's policy; params are alternating names and values
+func (s Sequence) stage(name string, source Sequence, params... interface{}) Sequence {
+	src := source.Describe()
+	return s.inheritPolicy(source).describeAs(Description{name, descParams(params), &src})
+}
+
+//returns s annotated as a source stage; params are alternating names and values
+func (s Sequence) sourceStage(name string, params... interface{}) Sequence {
+	return s.describeAs(Description{name, descParams(params), nil})
+}
+
+func (s Sequence) describeAs(desc Description) Sequence {return Sequence{&describedSeq{s.Seq, desc}}}
+
+func descParams(params []interface{}) map[string]interface{} {
+	if len(params) == 0 {return nil}
+	p := map[string]interface{}{}
+	for i := 0; i + 1 < len(params); i += 2 {
+		p[params[i].(string)] = params[i + 1]
+	}
+	return p
+}
@@ -0,0 +1,144 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+//The dist package provides discrete probability distributions over int outcomes, like the ones the dice example computes by enumerating products of sequences
+package dist
+
+import "bytes"
+import "fmt"
+import "sort"
+import . "github.com/zot/seq"
+
+//a discrete distribution: the number of ways each outcome can occur.  Probabilities are counts divided by the Total
+type Distribution map[int]int64
+
+//returns a new Distribution counting the int elements of s
+func FromSeq(s Sequence) Distribution {
+	d := Distribution{}
+	s.Do(func(el El){d[el.(int)]++})
+	return d
+}
+
+//returns a new Distribution of a fair die with outcomes 1 through sides
+func Die(sides int) Distribution {
+	d := Distribution{}
+	for i := 1; i <= sides; i++ {d[i] = 1}
+	return d
+}
+
+//returns the number of ways any outcome can occur
+func (d Distribution) Total() int64 {
+	total := int64(0)
+	for _, count := range d {total += count}
+	return total
+}
+
+//returns the probability of outcome
+func (d Distribution) Probability(outcome int) float64 {
+	total := d.Total()
+	if total == 0 {return 0}
+	return float64(d[outcome]) / float64(total)
+}
+
+//returns the probability of an outcome for which test returns true
+func (d Distribution) ProbabilityOf(test func(outcome int) bool) float64 {
+	total, count := d.Total(), int64(0)
+	if total == 0 {return 0}
+	for outcome, c := range d {
+		if test(outcome) {count += c}
+	}
+	return float64(count) / float64(total)
+}
+
+//returns the outcomes of d in increasing order
+func (d Distribution) Outcomes() []int {
+	outcomes := make([]int, 0, len(d))
+	for outcome := range d {outcomes = append(outcomes, outcome)}
+	sort.SortInts(outcomes)
+	return outcomes
+}
+
+//returns a new SequentialSeq of [outcome, count] sequences in increasing order of outcome
+func (d Distribution) ToSeq() Sequence {
+	pairs := make([]interface{}, 0, len(d))
+	for _, outcome := range d.Outcomes() {pairs = append(pairs, From(outcome, d[outcome]))}
+	return From(pairs...)
+}
+
+func (d Distribution) String() string {
+	buf := bytes.NewBufferString("{")
+	total := d.Total()
+	for i, outcome := range d.Outcomes() {
+		if i > 0 {buf.WriteString(", ")}
+		fmt.Fprintf(buf, "%d: %d (%.2f%%)", outcome, d[outcome], float64(d[outcome]) * 100 / float64(total))
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+//returns a new Distribution of combine(a, b) for independent outcomes a of d1 and b of d2
+func Combine(d1, d2 Distribution, combine func(a, b int) int) Distribution {
+	result := Distribution{}
+	for a, countA := range d1 {
+		for b, countB := range d2 {result[combine(a, b)] += countA * countB}
+	}
+	return result
+}
+
+//returns a new Distribution of the sum of independent outcomes of d1 and d2
+func Convolve(d1, d2 Distribution) Distribution {return Combine(d1, d2, func(a, b int) int {return a + b})}
+
+//returns a new Distribution of the larger of independent outcomes of d1 and d2
+func Max(d1, d2 Distribution) Distribution {
+	return Combine(d1, d2, func(a, b int) int {
+		if a > b {return a}
+		return b
+	})
+}
+
+//returns a new Distribution of the largest of n independent outcomes of d
+func MaxOfN(d Distribution, n int) Distribution {
+	if n <= 0 {return Distribution{}}
+	result := d
+	for i := 1; i < n; i++ {result = Max(result, d)}
+	return result
+}
+
+//the result of comparing independent outcomes of two distributions
+type Comparison struct {
+	Wins, Ties, Losses int64
+	//the distribution of attack - defense; winning margins are the positive outcomes
+	Margins Distribution
+}
+
+//returns the number of ways the comparison can come out
+func (c Comparison) Total() int64 {return c.Wins + c.Ties + c.Losses}
+
+//returns the probability that attack wins
+func (c Comparison) WinProbability() float64 {
+	if c.Total() == 0 {return 0}
+	return float64(c.Wins) / float64(c.Total())
+}
+
+//returns a new Distribution of the margins of the wins alone
+func (c Comparison) WinMargins() Distribution {
+	result := Distribution{}
+	for margin, count := range c.Margins {
+		if margin > 0 {result[margin] = count}
+	}
+	return result
+}
+
+//compares independent outcomes of attack and defense, where the higher outcome wins
+func Compare(attack, defense Distribution) Comparison {
+	result := Comparison{Margins: Combine(attack, defense, func(a, b int) int {return a - b})}
+	for margin, count := range result.Margins {
+		switch {
+		case margin > 0: result.Wins += count
+		case margin < 0: result.Losses += count
+		default: result.Ties += count
+		}
+	}
+	return result
+}
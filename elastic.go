@@ -0,0 +1,79 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "sync"
+
+//bounds for an elastic pool of workers: there are always at least Min workers, up to Max, and workers beyond Min exit after a whole Idle period of nanoseconds without work
+type Elastic struct {
+	Min, Max int
+	Idle int64
+}
+
+//the Elastic settings CDoElastic uses for zero fields
+var DefaultElastic = Elastic{1, 64, 1e8}
+
+//applies f concurrently to each element of s, in no particular order, using a pool of worker goroutines which grows, up to e.Max, whenever an element arrives and every worker is busy, and shrinks back towards e.Min as workers sit idle.  Idle time is measured with s's Clock
+func (s Sequence) CDoElastic(f func(el El), e Elastic) {
+	if e.Min <= 0 {e.Min = DefaultElastic.Min}
+	if e.Max <= 0 {e.Max = DefaultElastic.Max}
+	if e.Max < e.Min {e.Max = e.Min}
+	if e.Idle <= 0 {e.Idle = DefaultElastic.Idle}
+	clock := s.Clock()
+	work := make(chan El)
+	exited := make(chan bool)
+	var lock sync.Mutex
+	workers, finishing := 0, false
+	worker := func() {
+		//one timer per idle period: working during a period only marks it busy, and the timer is re-armed when it fires
+		idle, busy := clock.After(e.Idle), false
+		for {
+			select {
+			case el := <- work:
+				if closed(work) {
+					exited <- true
+					return
+				}
+				f(el)
+				busy = true
+			case <- idle:
+				if !busy {
+					lock.Lock()
+					if !finishing && workers > e.Min {
+						workers--
+						lock.Unlock()
+						return
+					}
+					lock.Unlock()
+				}
+				idle, busy = clock.After(e.Idle), false
+			}
+		}
+	}
+	spawn := func() {
+		lock.Lock()
+		defer lock.Unlock()
+		if workers < e.Max {
+			workers++
+			go worker()
+		}
+	}
+	for i := 0; i < e.Min; i++ {spawn()}
+	input := s.channel()
+	for el := <- input; !closed(input); el = <- input {
+		select {
+		case work <- el: continue
+		default:
+		}
+		spawn()
+		work <- el
+	}
+	lock.Lock()
+	finishing = true
+	remaining := workers
+	lock.Unlock()
+	close(work)
+	for ; remaining > 0; remaining-- {<- exited}
+}
@@ -0,0 +1,94 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "gob"
+import "io"
+import "os"
+
+//encodes values one at a time, like *gob.Encoder
+type Encoder interface {
+	Encode(e interface{}) os.Error
+}
+
+//decodes values one at a time, like *gob.Decoder
+type Decoder interface {
+	Decode(e interface{}) os.Error
+}
+
+//the encoded form of an element: either a nested sequence header, whose Len items follow it, or a Value
+type encodedEl struct {
+	IsSeq bool
+	Len int
+	Value interface{}
+}
+
+//encodes s with enc, recursing into nested sequences; concurrent sequences are traversed and encoded as sequential ones.  With a gob encoder, the element types must be registered with gob.Register
+func (s Sequence) Encode(enc Encoder) os.Error {
+	items := s.ToSlice()
+	if err := enc.Encode(&encodedEl{true, len(items), nil}); err != nil {return err}
+	for _, item := range items {
+		if err := encodeEl(enc, item); err != nil {return err}
+	}
+	return nil
+}
+
+//encodes one element with enc, recursing into nested sequences
+func encodeEl(enc Encoder, el El) os.Error {
+	if sub, ok := el.(Sequence); ok {return sub.Encode(enc)}
+	return enc.Encode(&encodedEl{false, 0, el})
+}
+
+//decodes one element that encodeEl wrote, returning nested sequences as SequentialSeqs
+func decodeEl(dec Decoder) (El, os.Error) {
+	var el encodedEl
+	if err := dec.Decode(&el); err != nil {return nil, err}
+	if el.IsSeq {return decodeItems(dec, el.Len)}
+	return el.Value, nil
+}
+
+//decodes a sequence that Encode wrote, returning it as nested SequentialSeqs
+func Decode(dec Decoder) (Sequence, os.Error) {
+	var header encodedEl
+	if err := dec.Decode(&header); err != nil {return From(), err}
+	if !header.IsSeq {return From(), seqError("Decode", "expected a sequence but found %T", header.Value)}
+	return decodeItems(dec, header.Len)
+}
+
+func decodeItems(dec Decoder, n int) (Sequence, os.Error) {
+	items := make([]interface{}, n)
+	for i := range items {
+		el, err := decodeEl(dec)
+		if err != nil {return From(), err}
+		items[i] = el
+	}
+	return Sequence{(*SequentialSeq)(&items)}, nil
+}
+
+//writes s to w in gob format
+func (s Sequence) WriteGob(w io.Writer) os.Error {return s.Encode(gob.NewEncoder(w))}
+
+//reads a sequence that WriteGob wrote to r
+func ReadGob(r io.Reader) (Sequence, os.Error) {return Decode(gob.NewDecoder(r))}
+
+//makes Encoders and Decoders for streams of elements, so the code that writes elements to files can use any format
+type Codec interface {
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+type gobCodec struct{}
+
+func (c gobCodec) NewEncoder(w io.Writer) Encoder {return gob.NewEncoder(w)}
+func (c gobCodec) NewDecoder(r io.Reader) Decoder {return gob.NewDecoder(r)}
+
+//the Codec for gob format; element types must be registered with gob.Register
+var GobCodec Codec = gobCodec{}
+
+//returns codecOpt[0], or GobCodec if it is not given
+func codecOf(codecOpt []Codec) Codec {
+	if len(codecOpt) > 0 && codecOpt[0] != nil {return codecOpt[0]}
+	return GobCodec
+}
@@ -0,0 +1,108 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "fmt"
+import "os"
+import "sync"
+
+//holds the error from the most recent traversal of an errSeq
+type errHolder struct {
+	lock sync.Mutex
+	err os.Error
+}
+
+func (h *errHolder) set(err os.Error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.err = err
+}
+
+func (h *errHolder) get() os.Error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.err
+}
+
+//a ConcurrentSeq whose generator can fail
+type errSeq struct {
+	Seq
+	holder *errHolder
+}
+
+func (s *errSeq) unwrap() Seq {return s.Seq}
+func (s *errSeq) Err() os.Error {return s.holder.get()}
+func (s *errSeq) Rest() Sequence {return Sequence{&errSeq{s.Seq.Rest().Seq, s.holder}}}
+
+//returns a new ConcurrentSeq of the items f writes to the channel, like Gen, for generators that can fail: a non-nil result from f ends the sequence and is available from Err after the traversal
+func GenErr(f func(c SeqChan) os.Error) Sequence {
+	holder := &errHolder{}
+	return Sequence{&errSeq{Gen(func(c SeqChan){holder.set(f(c))}).Seq, holder}}
+}
+
+//returns the error that stopped the most recent traversal of s, or nil if s cannot fail or finished normally
+func (s Sequence) Err() os.Error {
+	for _, layer := range s.layers() {
+		if e, ok := layer.(interface{Err() os.Error}); ok {return e.Err()}
+	}
+	return nil
+}
+
+//returns a new ConcurrentSeq of the elements of each sequence that sources returns, in order.  Each source is opened only after the previous one's sequence is exhausted.  An error from opening a source, or from a sequence's Err after it is read, ends the chain and is available from its Err method
+func Chain(sources... func() (Sequence, os.Error)) Sequence {
+	return GenErr(func(c SeqChan) os.Error {
+		for _, source := range sources {
+			seq, err := source()
+			if err != nil {return err}
+			aborted := false
+			seq.Find(func(el El)bool{
+				c <- el
+				aborted = closed(c)
+				return aborted
+			})
+			if aborted {return nil}
+			if err := seq.Err(); err != nil {return err}
+		}
+		return nil
+	}).sourceStage("Chain", "sources", len(sources))
+}
+
+//an error from processing one element of a sequence
+type ElError struct {
+	El El
+	Err os.Error
+}
+
+func (e *ElError) String() string {return fmt.Sprintf("%v: %v", e.El, e.Err)}
+
+//applies f concurrently to each element of s, like CDo, and returns an *ElError for each element f fails on, in no particular order.  If stopOnErrorOpt[0] is true, no more elements are started after the first failure, although ones already started finish and report their own failures
+func (s Sequence) CDoErr(f func(el El) os.Error, stopOnErrorOpt... bool) []os.Error {
+	stopOnError := len(stopOnErrorOpt) > 0 && stopOnErrorOpt[0]
+	var lock sync.Mutex
+	errs := []os.Error{}
+	failed := func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(errs) > 0
+	}
+	input := s
+	if stopOnError {
+		input = Gen(func(c SeqChan){
+			s.While(func(el El)bool{
+				if failed() {return false}
+				c <- el
+				return true
+			})
+		}).inheritMetrics(s)
+	}
+	input.cdo("CDoErr", func(el El){
+		if err := f(el); err != nil {
+			lock.Lock()
+			errs = append(errs, &ElError{el, err})
+			lock.Unlock()
+		}
+	})
+	return errs
+}
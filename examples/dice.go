@@ -22,13 +22,6 @@ func add(i int, s Sequence) Sequence {
 	})
 }
 
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
-
 func main() {
 	d4 := add(1, SUpto(4))
 	d6 := add(1, SUpto(6))
@@ -39,7 +32,7 @@ func main() {
 	rank := map[Seq]int{d4.Seq:0, d6.Seq:1, d8.Seq:2, d10.Seq:3}
 	sets := map[string]int{}
 	//attempts is [[label, [score, ...]]...]
-	attempts := From(dice, dice, dice).Product().Filter(func(d El)bool{
+	attempts := From(dice, dice, dice).LazyProduct().Filter(func(d El)bool{
 		oldRank := -1
 		result := true
 		// change this to a fold!
@@ -55,7 +48,8 @@ func main() {
 		Pretty(el.(Sequence), names, buf)
 		io.WriteString(buf, ">")
 		return From(buf.String(), el.(Sequence).Product().Map(func(el El)El{
-			return el.(Sequence).Fold(0, func(acc, el El)El{return max(acc.(int), el.(int))})
+			best, _ := el.(Sequence).Max(func(a, b El)bool{return a.(int) < b.(int)})
+			return best
 		}))
 	})
 	println("#sets:", len(sets))
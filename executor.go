@@ -0,0 +1,70 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+//runs the work of concurrent operators; Execute may run f before returning, or later on another goroutine, and may block until it has room for f
+type Executor interface {
+	Execute(f func())
+}
+
+type goExecutor struct{}
+
+func (e goExecutor) Execute(f func()) {go f()}
+
+//runs each function on a new goroutine
+var GoExecutor Executor = goExecutor{}
+
+type callerExecutor struct{}
+
+func (e callerExecutor) Execute(f func()) {f()}
+
+//runs each function on the calling goroutine before returning, so a stage pinned to it does its work one element at a time in its own loop
+var CallerExecutor Executor = callerExecutor{}
+
+//an Executor with a fixed number of goroutines, which can be shared by several stages to bound their combined concurrency
+type Pool struct {
+	jobs chan func()
+}
+
+//returns a new Pool of n goroutines (at least 1); NewPool(1) runs functions serially, in the order they are given
+func NewPool(n int) *Pool {
+	if n < 1 {n = 1}
+	p := &Pool{make(chan func())}
+	for i := 0; i < n; i++ {
+		go func() {
+			for f := range p.jobs {f()}
+		}()
+	}
+	return p
+}
+
+//waits for one of p's goroutines to be free and has it run f
+func (p *Pool) Execute(f func()) {p.jobs <- f}
+
+//stops p's goroutines once they finish their current functions; p must not be given any more
+func (p *Pool) Close() {close(p.jobs)}
+
+type executorSeq struct {
+	Seq
+	executor Executor
+}
+
+func (s *executorSeq) unwrap() Seq {return s.Seq}
+func (s *executorSeq) Rest() Sequence {return s.Seq.Rest().RunOn(s.executor)}
+func (s *executorSeq) Describe() Description {
+	src := Sequence{s.Seq}.Describe()
+	return Description{"RunOn", nil, &src}
+}
+
+//returns a sequence with the same elements as s whose concurrent operators (CMap and the operators built on it) run their functions on e, so a stage can be pinned to a pool of its own, a single serial goroutine, or the stage's own goroutine.  Without RunOn, each operator uses its own goroutines
+func (s Sequence) RunOn(e Executor) Sequence {return Sequence{&executorSeq{s.Seq, e}}}
+
+//returns the Executor s was given with RunOn, or nil
+func (s Sequence) executor() Executor {
+	for _, layer := range s.layers() {
+		if e, ok := layer.(*executorSeq); ok {return e.executor}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "os"
+
+//returns a new ConcurrentSeq of the results of applying f to each chunkSize-byte chunk of the file at path, in file order.  Chunks are read and processed concurrently, with the same bounds as CMap; sizePowerOpt will default to {6}.  Chunks are split at byte offsets, so f must cope with records that span chunks.  The first open or read error, in file order, ends the sequence and is available from its Err method
+func ProcessFileParallel(path string, chunkSize int, f func(chunk []byte) El, sizePowerOpt... uint) Sequence {
+	return GenErr(func(c SeqChan) os.Error {
+		if chunkSize <= 0 {return seqError("ProcessFileParallel", "chunk size %d is not positive", chunkSize)}
+		file, err := os.Open(path, os.O_RDONLY, 0)
+		if err != nil {return err}
+		defer file.Close()
+		size, err := file.Seek(0, 2)
+		if err != nil {return err}
+		chunks := int((size + int64(chunkSize) - 1) / int64(chunkSize))
+		var readErr os.Error
+		CUpto(chunks).CMap(func(el El) El {
+			buf := make([]byte, chunkSize)
+			n, err := file.ReadAt(buf, int64(el.(int)) * int64(chunkSize))
+			if err != nil && err != os.EOF {return chunkError{err}}
+			return f(buf[:n])
+		}, sizePowerOpt...).Find(func(el El) bool {
+			if failed, isErr := el.(chunkError); isErr {
+				readErr = failed.err
+				return true
+			}
+			c <- el
+			return closed(c)
+		})
+		return readErr
+	}).sourceStage("ProcessFileParallel", "path", path, "chunkSize", chunkSize)
+}
+
+//the result CMap gives ProcessFileParallel for a chunk that could not be read, so the error stops the sequence when it reaches its place in file order
+type chunkError struct {err os.Error}
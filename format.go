@@ -0,0 +1,60 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "bytes"
+import "fmt"
+
+//the number of elements String and %v show before eliding the rest
+const StringLimit = 10
+
+//returns s's first StringLimit elements, like "[1, 2, 3]", with "..." if there are more.  Concurrent sequences are traversed to do this
+func (s Sequence) String() string {return s.format("%v", StringLimit)}
+
+//formats s for fmt: %v and %s print like String, %+v prints every element after s's Description, and other verbs are applied to each element.  A sequence that contains itself prints <cycle> where it recurs
+func (s Sequence) Format(f fmt.State, c int) {
+	verb := "%" + string(c)
+	if c == 'v' && f.Flag('+') {verb = "%+v"}
+	f.Write([]byte(s.formatVerb(verb, map[interface{}]bool{})))
+}
+
+//formats s as Format does for verb, with path holding the sequences already being formatted
+func (s Sequence) formatVerb(verb string, path map[interface{}]bool) string {
+	switch {
+	case verb == "%+v": return fmt.Sprintf("%v %s", s.Describe(), s.formatIn("%+v", -1, path))
+	case verb == "%v" || verb == "%s": return s.formatIn("%v", StringLimit, path)
+	}
+	return s.formatIn(verb, -1, path)
+}
+
+//formats up to limit elements of s with verb, or all of them if limit is negative
+func (s Sequence) format(verb string, limit int) string {return s.formatIn(verb, limit, map[interface{}]bool{})}
+
+//formats like format, with path holding the sequences already being formatted, as Pretty does, so nested sequences that lead back to one of them print <cycle> instead of recursing forever
+func (s Sequence) formatIn(verb string, limit int, path map[interface{}]bool) string {
+	if s.Seq != nil && hashable(s.Seq) {
+		if path[s.Seq] {return "<cycle>"}
+		path[s.Seq] = true
+		defer func(){path[s.Seq] = false, false}()
+	}
+	buf := bytes.NewBufferString("[")
+	count := 0
+	s.Find(func(el El)bool{
+		if count == limit {
+			buf.WriteString(", ...")
+			return true
+		}
+		if count > 0 {buf.WriteString(", ")}
+		if sub, isSeq := el.(Sequence); isSeq {
+			buf.WriteString(sub.formatVerb(verb, path))
+		} else {
+			fmt.Fprintf(buf, verb, el)
+		}
+		count++
+		return false
+	})
+	buf.WriteString("]")
+	return buf.String()
+}
@@ -0,0 +1,31 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+//the state of a machine RunFSM runs
+type State interface{}
+
+//calls emit with the elements of each sequence transition returns as it consumes s, and returns the final state
+func (s Sequence) runFSM(initial State, transition func(state State, el El) (State, Sequence), emit func(el El)) State {
+	state := initial
+	s.Do(func(el El){
+		var out Sequence
+		state, out = transition(state, el)
+		if out.Seq != nil {out.Do(emit)}
+	})
+	return state
+}
+
+//returns a new sequence of the same type as s consisting of the concatenation of the output sequences transition returns as it consumes s, threading the state from each call to the next, starting with initial.  transition may return a Sequence with a nil Seq for no output
+func (s Sequence) RunFSM(initial State, transition func(state State, el El) (State, Sequence)) Sequence {
+	return s.produce("RunFSM", func(emit func(el El)){s.runFSM(initial, transition, emit)})
+}
+
+//runs the machine over s like RunFSM, returning its output as a SequentialSeq along with the final state
+func (s Sequence) RunFSMState(initial State, transition func(state State, el El) (State, Sequence)) (Sequence, State) {
+	slice := make([]interface{}, 0, s.quickLen(8))
+	state := s.runFSM(initial, transition, func(el El){slice = append(slice, el)})
+	return Sequence{(*SequentialSeq)(&slice)}, state
+}
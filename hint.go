@@ -0,0 +1,46 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+//Seqs, and the Rows given to FromRows, can implement LenHinter to tell operators how many elements to expect without running them, so results can be allocated up front; exact is false when n is only an estimate
+type LenHinter interface {
+	LenHint() (n int, exact bool)
+}
+
+//a Seq decorated with a length hint
+type hintedSeq struct {
+	Seq
+	n int
+	exact bool
+}
+
+func (s *hintedSeq) unwrap() Seq {return s.Seq}
+func (s *hintedSeq) LenHint() (int, bool) {return s.n, s.exact}
+func (s *hintedSeq) Rest() Sequence {
+	if s.n > 0 {return s.Seq.Rest().WithLenHint(s.n - 1, s.exact)}
+	return s.Seq.Rest()
+}
+//returns the hinted length if it is exact, rather than running a concurrent sequence to count it
+func (s *hintedSeq) Len() int {
+	if s.exact {return s.n}
+	return s.Seq.Len()
+}
+
+//returns a sequence with the same elements as s which hints that it has n of them; if exact is true, Len trusts the hint
+func (s Sequence) WithLenHint(n int, exact bool) Sequence {return Sequence{&hintedSeq{s.Seq, n, exact}}}
+
+//returns the first length hint among s's layers, and whether there was one
+func (s Sequence) lenHint() (n int, exact bool, ok bool) {
+	for _, layer := range s.layers() {
+		if h, isHinter := layer.(LenHinter); isHinter {
+			n, exact = h.LenHint()
+			return n, exact, true
+		}
+	}
+	return 0, false, false
+}
+
+//like Gen, but declares that f will produce exactly n elements
+func GenSized(n int, f func(c SeqChan)) Sequence {return Gen(f).WithLenHint(n, true)}
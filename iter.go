@@ -0,0 +1,31 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+//returns a push iterator over s: calling it with yield calls yield with each element of s in order, stopping early if yield returns false
+func (s Sequence) All() func(yield func(el El) bool) {
+	return func(yield func(el El) bool) {s.While(yield)}
+}
+
+//returns a push iterator over the positions and elements of s, like All
+func (s Sequence) All2() func(yield func(i int, el El) bool) {
+	return func(yield func(i int, el El) bool) {
+		i := 0
+		s.While(func(el El) bool {
+			i++
+			return yield(i - 1, el)
+		})
+	}
+}
+
+//returns a new ConcurrentSeq of the elements a push iterator yields; each traversal calls it again, and abandoning the traversal makes yield return false
+func FromIter(it func(yield func(el El) bool)) Sequence {
+	return Gen(func(c SeqChan){
+		it(func(el El) bool {
+			c <- el
+			return !closed(c)
+		})
+	}).sourceStage("FromIter")
+}
@@ -0,0 +1,73 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+//returns the next element from c and whether there was one
+func receive(c SeqChan) (El, bool) {
+	el := <- c
+	if closed(c) {return nil, false}
+	return el, true
+}
+
+//joins s and other, which are both sorted according to cmp, in one pass, emitting combine(l, r) for each pair of matching elements and, if keepLeft or keepRight is set, combine(l, nil) or combine(nil, r) for elements of s or other without a match.  Only one run of equal elements of other is held in memory at a time
+func (s Sequence) mergeJoin(stage string, other Sequence, cmp func(a, b El) int, combine func(l, r El) El, keepLeft, keepRight bool) Sequence {
+	return Gen(func(c SeqChan){
+		lc, rc := s.channel(), other.channel()
+		defer close(lc)
+		defer close(rc)
+		l, lok := receive(lc)
+		r, rok := receive(rc)
+		for lok && rok {
+			switch d := cmp(l, r); {
+			case d < 0:
+				if keepLeft {c <- combine(l, nil)}
+				l, lok = receive(lc)
+			case d > 0:
+				if keepRight {c <- combine(nil, r)}
+				r, rok = receive(rc)
+			default:
+				run := []interface{}{r}
+				for r, rok = receive(rc); rok && cmp(l, r) == 0; r, rok = receive(rc) {run = append(run, r)}
+				for ; lok && cmp(l, run[0]) == 0; l, lok = receive(lc) {
+					for _, match := range run {c <- combine(l, match)}
+				}
+			}
+			if closed(c) {return}
+		}
+		for ; keepLeft && lok; l, lok = receive(lc) {c <- combine(l, nil)}
+		for ; keepRight && rok; r, rok = receive(rc) {c <- combine(nil, r)}
+	}).stage(stage, s)
+}
+
+//returns a new ConcurrentSeq of combine(l, r) for each element l of s and r of other where cmp(l, r) is 0, reading s and other, which must both be sorted according to cmp, in a single pass.  Memory use is constant apart from runs of equal elements of other, which are held while the matching elements of s go by
+func (s Sequence) MergeJoin(other Sequence, cmp func(a, b El) int, combine func(l, r El) El) Sequence {
+	return s.mergeJoin("MergeJoin", other, cmp, combine, false, false)
+}
+
+//like MergeJoin, but also emits combine(l, nil) for each element l of s that matches nothing in other
+func (s Sequence) LeftJoin(other Sequence, cmp func(a, b El) int, combine func(l, r El) El) Sequence {
+	return s.mergeJoin("LeftJoin", other, cmp, combine, true, false)
+}
+
+//like MergeJoin, but also emits combine(l, nil) for each element l of s that matches nothing in other and combine(nil, r) for each element r of other that matches nothing in s
+func (s Sequence) OuterJoin(other Sequence, cmp func(a, b El) int, combine func(l, r El) El) Sequence {
+	return s.mergeJoin("OuterJoin", other, cmp, combine, true, true)
+}
+
+//returns a new sequence of the same type as s pairing each element l of s with the sequence of the elements r of other where rightKey(r) equals leftKey(l), in their order in other, as [l, [r, ...]] sequences; l is paired with an empty sequence when nothing matches.  other is indexed in memory and s is streamed, so other should be the smaller side
+func (s Sequence) GroupJoin(other Sequence, leftKey, rightKey func(el El) interface{}) Sequence {
+	return s.produce("GroupJoin", func(emit func(el El)){
+		index := map[interface{}][]interface{}{}
+		other.Do(func(el El){
+			k := rightKey(el)
+			index[k] = append(index[k], el)
+		})
+		s.Do(func(el El){
+			matches := index[leftKey(el)]
+			if matches == nil {matches = []interface{}{}}
+			emit(From(el, Sequence{(*SequentialSeq)(&matches)}))
+		})
+	})
+}
@@ -0,0 +1,136 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "reflect"
+
+//the element equality used when none is given
+func defaultEqual(a, b El) bool {return reflect.DeepEqual(a, b)}
+
+//returns the equality function from an optional argument, defaulting to defaultEqual
+func equalOf(eqOpt []func(a, b El) bool) func(a, b El) bool {
+	if len(eqOpt) > 0 && eqOpt[0] != nil {return eqOpt[0]}
+	return defaultEqual
+}
+
+//returns the table where table[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+func lcsTable(a, b []interface{}, eq func(a, b El) bool) [][]int {
+	table := make([][]int, len(a) + 1)
+	for i := range table {table[i] = make([]int, len(b) + 1)}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case eq(a[i], b[j]): table[i][j] = table[i + 1][j + 1] + 1
+			case table[i + 1][j] >= table[i][j + 1]: table[i][j] = table[i + 1][j]
+			default: table[i][j] = table[i][j + 1]
+			}
+		}
+	}
+	return table
+}
+
+//returns a new SequentialSeq holding a longest subsequence common to s and other, taking elements from s; eqOpt defaults to reflect.DeepEqual
+func (s Sequence) LCS(other Sequence, eqOpt... func(a, b El) bool) Sequence {
+	eq := equalOf(eqOpt)
+	a, b := s.ToSlice(), other.ToSlice()
+	table := lcsTable(a, b, eq)
+	result := make([]interface{}, 0, table[0][0])
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case eq(a[i], b[j]):
+			result = append(result, a[i])
+			i++
+			j++
+		case table[i + 1][j] >= table[i][j + 1]: i++
+		default: j++
+		}
+	}
+	return Sequence{(*SequentialSeq)(&result)}
+}
+
+//returns a new SequentialSeq holding a longest strictly increasing subsequence of s, according to less
+func (s Sequence) LIS(less func(a, b El) bool) Sequence {
+	items := s.ToSlice()
+	//tails[k] is the index of the smallest item ending an increasing subsequence of length k + 1
+	tails := make([]int, 0, 8)
+	prev := make([]int, len(items))
+	for i, item := range items {
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if less(items[tails[mid]], item) {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		prev[i] = -1
+		if lo > 0 {prev[i] = tails[lo - 1]}
+		if lo == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[lo] = i
+		}
+	}
+	result := make([]interface{}, len(tails))
+	if len(tails) > 0 {
+		for i, k := tails[len(tails) - 1], len(tails) - 1; k >= 0; i, k = prev[i], k - 1 {result[k] = items[i]}
+	}
+	return Sequence{(*SequentialSeq)(&result)}
+}
+
+//returns a new SequentialSeq of Keep, Insert, and Delete Edits that transforms s into other, keeping a longest common subsequence; eqOpt defaults to reflect.DeepEqual
+func (s Sequence) Diff(other Sequence, eqOpt... func(a, b El) bool) Sequence {
+	eq := equalOf(eqOpt)
+	a, b := s.ToSlice(), other.ToSlice()
+	table := lcsTable(a, b, eq)
+	edits := make([]interface{}, 0, len(a) + len(b) - table[0][0])
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case eq(a[i], b[j]):
+			edits = append(edits, Edit{Keep, a[i], b[j]})
+			i++
+			j++
+		case table[i + 1][j] >= table[i][j + 1]:
+			edits = append(edits, Edit{Delete, a[i], nil})
+			i++
+		default:
+			edits = append(edits, Edit{Insert, nil, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {edits = append(edits, Edit{Delete, a[i], nil})}
+	for ; j < len(b); j++ {edits = append(edits, Edit{Insert, nil, b[j]})}
+	return Sequence{(*SequentialSeq)(&edits)}
+}
+
+//returns a new sequence of the same type as s made by replaying edits, a sequence of Edits like the ones Diff or Align produce, against s: Keep copies the next element of s, Delete skips it, Substitute skips it and emits B, and Insert emits B.  Elements of s left over after the last edit are copied unchanged.  Edits that are not Edits or that run past the end of s are errors, and s's Policy decides whether to panic or to skip them
+func (s Sequence) ApplyPatch(edits Sequence) Sequence {
+	return s.produce("ApplyPatch", func(emit func(el El)){
+		base := s.ToSlice()
+		i := 0
+		edits.Do(func(el El){
+			edit, isEdit := el.(Edit)
+			switch {
+			case !isEdit:
+				s.check(seqError("ApplyPatch", "edit is a %T, not an Edit", el))
+				return
+			case edit.Op == Insert:
+				emit(edit.B)
+				return
+			case i >= len(base):
+				s.check(seqError("ApplyPatch", "%v past the end of a sequence of %d elements", edit.Op, len(base)))
+				return
+			}
+			switch edit.Op {
+			case Keep: emit(base[i])
+			case Substitute: emit(edit.B)
+			}
+			i++
+		})
+		for ; i < len(base); i++ {emit(base[i])}
+	})
+}
@@ -0,0 +1,156 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "container/heap"
+
+//the first element one of Race's sources produced, if it produced any
+type raceEntry struct {
+	source int
+	el El
+	ok bool
+}
+
+//returns a new ConcurrentSeq which starts all of seqs at once and mirrors whichever one produces an element first, abandoning the others, as for hedged requests sent to several backends.  If none of them produces anything, the result is empty
+func Race(seqs... Sequence) Sequence {
+	return Gen(func(c SeqChan){
+		chans := make([]SeqChan, len(seqs))
+		entries := make(chan raceEntry, len(seqs))
+		for i, s := range seqs {
+			chans[i] = s.channel()
+			go func(i int) {
+				el, ok := receive(chans[i])
+				entries <- raceEntry{i, el, ok}
+			}(i)
+		}
+		winner := raceEntry{-1, nil, false}
+		for i := 0; i < len(seqs) && !winner.ok; i++ {winner = <- entries}
+		for i, ch := range chans {
+			if i != winner.source || !winner.ok {close(ch)}
+		}
+		if !winner.ok {return}
+		ch := chans[winner.source]
+		defer close(ch)
+		for el, ok := winner.el, true; ok; el, ok = receive(ch) {
+			c <- el
+			if closed(c) {return}
+		}
+	}).sourceStage("Race", "sources", len(seqs))
+}
+
+//returns a new sequence of the same type as s of [a, b] sequences pairing the elements of s and s2 in order, continuing until both have ended, with fillA standing in for the missing elements of s and fillB for those of s2 once either runs out
+func (s Sequence) ZipLongest(s2 Sequence, fillA, fillB El) Sequence {
+	return s.produce("ZipLongest", func(emit func(el El)){
+		c1, c2 := s.channel(), s2.channel()
+		defer close(c1)
+		defer close(c2)
+		a, aok := receive(c1)
+		b, bok := receive(c2)
+		for aok || bok {
+			if !aok {a = fillA}
+			if !bok {b = fillB}
+			emit(From(a, b))
+			if aok {a, aok = receive(c1)}
+			if bok {b, bok = receive(c2)}
+		}
+	})
+}
+
+//returns a new ConcurrentSeq taking one element from each of seqs in turn, skipping the ones that have ended, until all of them have ended, where CAppend would drain each source before starting the next.  Each source is read ahead by one element, and a turn goes to the first source after the last one taken which has an element ready, so a source that is slow to produce is passed over rather than holding up the rest
+func MergeRoundRobin(seqs... Sequence) Sequence {
+	return Gen(func(c SeqChan){
+		chans := make([]SeqChan, len(seqs))
+		slots := make([]chan El, len(seqs))
+		ready, quit := make(chan bool, 1), make(chan bool)
+		for i, s := range seqs {
+			chans[i], slots[i] = s.channel(), make(chan El, 1)
+			go func(ch SeqChan, slot chan El) {
+				defer signal(ready)
+				defer close(slot)
+				for el := <- ch; !closed(ch); el = <- ch {
+					select {
+					case slot <- el: signal(ready)
+					case <- quit: return
+					}
+				}
+			}(chans[i], slots[i])
+		}
+		defer func() {
+			close(quit)
+			for _, ch := range chans {close(ch)}
+		}()
+		ended := make([]bool, len(seqs))
+		for live, next := len(seqs), 0; live > 0; {
+			taken := false
+			for k := 0; k < len(slots) && !taken; k++ {
+				i := (next + k) % len(slots)
+				if ended[i] {continue}
+				select {
+				case el := <- slots[i]:
+					if closed(slots[i]) {
+						ended[i] = true
+						live--
+					} else {
+						c <- el
+						if closed(c) {return}
+						next, taken = i + 1, true
+					}
+				default:
+				}
+			}
+			if !taken && live > 0 {<- ready}
+		}
+	}).sourceStage("MergeRoundRobin", "sources", len(seqs))
+}
+
+//the next element of one of MergeBy's sources
+type mergeHead struct {
+	head El
+	source int
+}
+
+//the heads of MergeBy's sources, as a heap ordered by their elements and then by their sources' positions
+type mergeHeap struct {
+	heads []*mergeHead
+	less func(a, b El) bool
+}
+
+func (h *mergeHeap) Len() int {return len(h.heads)}
+func (h *mergeHeap) Less(i, j int) bool {
+	a, b := h.heads[i], h.heads[j]
+	return h.less(a.head, b.head) || !h.less(b.head, a.head) && a.source < b.source
+}
+func (h *mergeHeap) Swap(i, j int) {h.heads[i], h.heads[j] = h.heads[j], h.heads[i]}
+func (h *mergeHeap) Push(x interface{}) {h.heads = append(h.heads, x.(*mergeHead))}
+func (h *mergeHeap) Pop() interface{} {
+	last := len(h.heads) - 1
+	head := h.heads[last]
+	h.heads = h.heads[:last]
+	return head
+}
+
+//returns a new ConcurrentSeq merging seqs, which should each be sorted according to less, into one sorted sequence, by always emitting the smallest of their next elements.  Equal elements come out in the order of their sources in seqs.  Only one element from each source is held at a time, so this is suitable for merging sorted runs or time-ordered streams of any length
+func MergeBy(less func(a, b El) bool, seqs... Sequence) Sequence {
+	return Gen(func(c SeqChan){
+		chans := make([]SeqChan, len(seqs))
+		for i, s := range seqs {chans[i] = s.channel()}
+		defer func() {
+			for _, ch := range chans {close(ch)}
+		}()
+		heads := &mergeHeap{make([]*mergeHead, 0, len(seqs)), less}
+		for i, ch := range chans {
+			if el, ok := receive(ch); ok {heap.Push(heads, &mergeHead{el, i})}
+		}
+		for heads.Len() > 0 {
+			next := heap.Pop(heads).(*mergeHead)
+			c <- next.head
+			if closed(c) {return}
+			if el, ok := receive(chans[next.source]); ok {
+				next.head = el
+				heap.Push(heads, next)
+			}
+		}
+	}).sourceStage("MergeBy", "sources", len(seqs))
+}
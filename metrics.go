@@ -0,0 +1,73 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+//receives instrumentation from the concurrent operators (CMap, CFilter, CFlatMap, and CDo); stage is the operator's name.  Methods may be called from several goroutines at once
+type Metrics interface {
+	//an element was read from the operator's input
+	In(stage string)
+	//an element was sent to the operator's output
+	Out(stage string)
+	//the number of elements the operator's workers are currently processing
+	InFlight(stage string, n int)
+	//a worker spent ns nanoseconds processing one element
+	Busy(stage string, ns int64)
+}
+
+type noMetrics struct{}
+
+func (m noMetrics) In(stage string) {}
+func (m noMetrics) Out(stage string) {}
+func (m noMetrics) InFlight(stage string, n int) {}
+func (m noMetrics) Busy(stage string, ns int64) {}
+
+//Metrics that ignore everything
+var NoMetrics Metrics = noMetrics{}
+
+//the Metrics for sequences that have not been given any with WithMetrics
+var DefaultMetrics = NoMetrics
+
+type metricsSeq struct {
+	Seq
+	metrics Metrics
+}
+
+func (s *metricsSeq) unwrap() Seq {return s.Seq}
+func (s *metricsSeq) Rest() Sequence {return s.Seq.Rest().WithMetrics(s.metrics)}
+
+//returns a sequence with the same elements as s whose concurrent operators report to m.  The sequences those operators produce report to m too, so instrumenting the source of a pipeline instruments its concurrent stages
+func (s Sequence) WithMetrics(m Metrics) Sequence {return Sequence{&metricsSeq{s.Seq, m}}}
+
+//returns the Metrics s's concurrent operators report to
+func (s Sequence) Metrics() Metrics {
+	if m := s.metricsOf(); m != nil {return m}
+	return DefaultMetrics
+}
+
+//returns the Metrics s was given with WithMetrics, or nil
+func (s Sequence) metricsOf() Metrics {
+	for _, layer := range s.layers() {
+		if m, ok := layer.(*metricsSeq); ok {return m.metrics}
+	}
+	return nil
+}
+
+//returns result reporting to the Metrics source was given, if any
+func (result Sequence) inheritMetrics(source Sequence) Sequence {
+	if m := source.metricsOf(); m != nil {return result.WithMetrics(m)}
+	return result
+}
+
+//returns f, reporting the time each call takes to m as stage's busy time unless m ignores everything
+func (s Sequence) timed(stage string, m Metrics, f func(el El) El) func(el El) El {
+	if _, silent := m.(noMetrics); silent {return f}
+	clock := s.Clock()
+	return func(el El) El {
+		start := clock.Now()
+		result := f(el)
+		m.Busy(stage, clock.Now() - start)
+		return result
+	}
+}
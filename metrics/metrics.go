@@ -0,0 +1,146 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+//The metrics package collects the instrumentation seq's concurrent operators report, per pipeline and stage, and exposes it
+//through expvar (as JSON) and in the Prometheus text format.  Instrument a pipeline with s.WithMetrics(metrics.For("name"))
+package metrics
+
+import "expvar"
+import "fmt"
+import "http"
+import "io"
+import "os"
+import "sort"
+import "sync"
+import "github.com/zot/seq"
+
+//the counters for one stage of a pipeline
+type Stage struct {
+	In, Out int64
+	InFlight int
+	BusyNs int64
+}
+
+//a set of pipelines' counters; a Registry is an expvar.Var and an http.Handler serving the Prometheus text format
+type Registry struct {
+	lock sync.Mutex
+	pipelines map[string]map[string]*Stage
+}
+
+//returns a new, empty Registry
+func NewRegistry() *Registry {return &Registry{pipelines: map[string]map[string]*Stage{}}}
+
+//the Registry For uses
+var Default = NewRegistry()
+
+//the seq.Metrics for one pipeline of a Registry
+type pipelineMetrics struct {
+	registry *Registry
+	name string
+}
+
+//returns a seq.Metrics which counts into r under the pipeline name nameOpt[0], or "" if it is not given
+func (r *Registry) Pipeline(nameOpt... string) seq.Metrics {
+	name := ""
+	if len(nameOpt) > 0 {name = nameOpt[0]}
+	return &pipelineMetrics{r, name}
+}
+
+//returns a seq.Metrics which counts into Default under the pipeline name nameOpt[0], or "" if it is not given
+func For(nameOpt... string) seq.Metrics {return Default.Pipeline(nameOpt...)}
+
+//calls f with the counters for stage of the pipeline, creating them if necessary, while holding the registry's lock
+func (m *pipelineMetrics) update(stage string, f func(counters *Stage)) {
+	m.registry.lock.Lock()
+	defer m.registry.lock.Unlock()
+	stages := m.registry.pipelines[m.name]
+	if stages == nil {
+		stages = map[string]*Stage{}
+		m.registry.pipelines[m.name] = stages
+	}
+	counters := stages[stage]
+	if counters == nil {
+		counters = &Stage{}
+		stages[stage] = counters
+	}
+	f(counters)
+}
+
+func (m *pipelineMetrics) In(stage string) {m.update(stage, func(c *Stage){c.In++})}
+func (m *pipelineMetrics) Out(stage string) {m.update(stage, func(c *Stage){c.Out++})}
+func (m *pipelineMetrics) InFlight(stage string, n int) {m.update(stage, func(c *Stage){c.InFlight = n})}
+func (m *pipelineMetrics) Busy(stage string, ns int64) {m.update(stage, func(c *Stage){c.BusyNs += ns})}
+
+//returns a copy of the counters, by pipeline name and then stage
+func (r *Registry) Snapshot() map[string]map[string]Stage {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	result := map[string]map[string]Stage{}
+	for name, stages := range r.pipelines {
+		result[name] = map[string]Stage{}
+		for stage, counters := range stages {result[name][stage] = *counters}
+	}
+	return result
+}
+
+//calls f with each pipeline name, stage, and counters in the snapshot, sorted by name and then stage
+func eachStage(snapshot map[string]map[string]Stage, f func(name, stage string, counters Stage)) {
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {names = append(names, name)}
+	sort.SortStrings(names)
+	for _, name := range names {
+		stages := make([]string, 0, len(snapshot[name]))
+		for stage := range snapshot[name] {stages = append(stages, stage)}
+		sort.SortStrings(stages)
+		for _, stage := range stages {f(name, stage, snapshot[name][stage])}
+	}
+}
+
+//returns the counters as JSON, {"pipeline": {"stage": {"In": ..., ...}}}, which makes a Registry an expvar.Var
+func (r *Registry) String() string {
+	buf := make([]byte, 0, 256)
+	buf = append(buf, '{')
+	lastName := ""
+	eachStage(r.Snapshot(), func(name, stage string, c Stage){
+		switch {
+		case len(buf) == 1: buf = append(buf, fmt.Sprintf("%q: {", name)...)
+		case name != lastName: buf = append(buf, fmt.Sprintf("}, %q: {", name)...)
+		default: buf = append(buf, ", "...)
+		}
+		lastName = name
+		buf = append(buf, fmt.Sprintf(`%q: {"In": %d, "Out": %d, "InFlight": %d, "BusyNs": %d}`, stage, c.In, c.Out, c.InFlight, c.BusyNs)...)
+	})
+	if len(buf) > 1 {buf = append(buf, '}')}
+	buf = append(buf, '}')
+	return string(buf)
+}
+
+//publishes r through expvar under name; like expvar.Publish, publishing the same name twice panics
+func (r *Registry) Publish(name string) {expvar.Publish(name, r)}
+
+//writes the counters to w in the Prometheus text exposition format, labeled by pipeline and stage
+func (r *Registry) WritePrometheus(w io.Writer) os.Error {
+	snapshot := r.Snapshot()
+	metrics := []struct {
+		name, kind, help string
+		value func(c Stage) string
+	}{
+		{"seq_elements_in_total", "counter", "Elements read by a stage.", func(c Stage) string {return fmt.Sprint(c.In)}},
+		{"seq_elements_out_total", "counter", "Elements emitted by a stage.", func(c Stage) string {return fmt.Sprint(c.Out)}},
+		{"seq_in_flight", "gauge", "Elements a stage's workers are processing.", func(c Stage) string {return fmt.Sprint(c.InFlight)}},
+		{"seq_busy_seconds_total", "counter", "Time a stage's workers have spent processing elements.", func(c Stage) string {return fmt.Sprint(float64(c.BusyNs) / 1e9)}},
+	}
+	for _, metric := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind); err != nil {return err}
+		var err os.Error
+		eachStage(snapshot, func(name, stage string, c Stage){
+			if err == nil {_, err = fmt.Fprintf(w, "%s{pipeline=%q,stage=%q} %s\n", metric.name, name, stage, metric.value(c))}
+		})
+		if err != nil {return err}
+	}
+	return nil
+}
+
+//serves the counters in the Prometheus text format, so a Registry can be registered as a scrape endpoint
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {r.WritePrometheus(w)}
@@ -0,0 +1,102 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+//returns a function that adds each element it is given to a SlidingWindow of the last n and calls full with the window whenever it holds n elements
+func slider(n int, full func(window *SlidingWindow)) func(el El) {
+	sz := uint(0)
+	for 1 << sz < n {sz++}
+	window := NewSlidingWindow(sz)
+	next := 0
+	return func(el El){
+		if window.Count() == n {window.RemoveFirst()}
+		window.Set(next, el)
+		next++
+		if window.Count() == n {full(window)}
+	}
+}
+
+//returns a new sequence of the same type as s of the folds of each run of n consecutive elements of s, starting with the first n; each fold starts from init and applies f to the elements in order.  There is one result per full window, so s with fewer than n elements produces none.  n < 1 is misuse, reported according to s's Policy
+func (s Sequence) WindowedFold(n int, init El, f func(acc, el El)El) Sequence {
+	return s.moving("WindowedFold", n, func(window *SlidingWindow) El {
+		acc := init
+		window.Each(func(index int, value interface{}){acc = f(acc, value)})
+		return acc
+	})
+}
+
+//returns a new sequence of the same type as s of the first smallest element, according to less, of each run of n consecutive elements of s, as for WindowedFold
+func (s Sequence) MovingMin(n int, less func(a, b El) bool) Sequence {
+	return s.moving("MovingMin", n, func(window *SlidingWindow) El {
+		result, _ := window.GetFirst()
+		window.Each(func(index int, value interface{}){if less(value, result) {result = value}})
+		return result
+	})
+}
+
+//returns a new sequence of the same type as s of the first largest element, according to less, of each run of n consecutive elements of s, as for WindowedFold
+func (s Sequence) MovingMax(n int, less func(a, b El) bool) Sequence {
+	return s.moving("MovingMax", n, func(window *SlidingWindow) El {
+		result, _ := window.GetFirst()
+		window.Each(func(index int, value interface{}){if less(result, value) {result = value}})
+		return result
+	})
+}
+
+//returns a new sequence of the same type as s of the float64 means of each run of n consecutive numbers in s, as for WindowedFold, for smoothing streamed values.  A non-numeric element is misuse, reported according to s's Policy; under Lenient, non-numeric elements are skipped
+func (s Sequence) MovingAvg(n int) Sequence {
+	if n < 1 {
+		s.check(seqError("MovingAvg", "window size %d is less than 1", n))
+		return s.produce("MovingAvg", func(emit func(el El)){})
+	}
+	return s.produce("MovingAvg", func(emit func(el El)){
+		push := slider(n, func(window *SlidingWindow){
+			sum := 0.0
+			window.Each(func(index int, value interface{}){sum += value.(float64)})
+			emit(sum / float64(n))
+		})
+		index := 0
+		s.Do(func(el El){
+			if f, ok := ToFloat64(el); ok {
+				push(f)
+			} else {
+				s.check(seqError("MovingAvg", "element %d is a %T, not a number", index, el))
+			}
+			index++
+		})
+	})
+}
+
+//returns a new sequence of the same type as s of [previous, current] sequences for each pair of consecutive elements of s, for computing deltas or spotting transitions without keeping the previous element in a closure, which would be unsafe under CMap.  s with fewer than 2 elements produces none
+func (s Sequence) Pairwise() Sequence {
+	return s.moving("Pairwise", 2, func(window *SlidingWindow) El {return From(window.ToSlice()...)})
+}
+
+//returns a new sequence of the same type as s of the windows of size consecutive elements of s starting every stride elements, as sequences, beginning with the first size elements; a stride less than size makes the windows overlap, and a larger one skips elements between them.  Only size elements are held at a time, in a SlidingWindow, and a partial window at the end is dropped.  A size or stride less than 1 is misuse, reported according to s's Policy
+func (s Sequence) Windows(size, stride int) Sequence {
+	if stride < 1 {
+		s.check(seqError("Windows", "stride %d is less than 1", stride))
+		return s.produce("Windows", func(emit func(el El)){})
+	}
+	if size < 1 {return s.moving("Windows", size, nil)}
+	return s.produce("Windows", func(emit func(el El)){
+		count := 0
+		s.Do(slider(size, func(window *SlidingWindow){
+			if count % stride == 0 {emit(From(window.ToSlice()...))}
+			count++
+		}))
+	})
+}
+
+//returns a new sequence of the same type as s of the results of calling aggregate on each full window of n consecutive elements of s
+func (s Sequence) moving(stage string, n int, aggregate func(window *SlidingWindow) El) Sequence {
+	if n < 1 {
+		s.check(seqError(stage, "window size %d is less than 1", n))
+		return s.produce(stage, func(emit func(el El)){})
+	}
+	return s.produce(stage, func(emit func(el El)){
+		s.Do(slider(n, func(window *SlidingWindow){emit(aggregate(window))}))
+	})
+}
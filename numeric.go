@@ -0,0 +1,115 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+//a sequential sequence of ints stored unboxed; its own Map, Filter, and Fold work on ints directly and elements are only boxed when they leave through Find
+type IntSeq []int
+
+//returns a new IntSeq sequence over ints, which is not copied
+func FromInts(ints []int) Sequence {
+	s := IntSeq(ints)
+	return Sequence{&s}
+}
+
+//IntSeqs are not concurrent; return false
+func (s *IntSeq) IsConcurrent() bool {return false}
+
+//returns the first item for which f returns true or nil if none is found
+func (s *IntSeq) Find(f func(el El)bool) El {
+	for _, n := range *s {
+		if f(n) {return n}
+	}
+	return nil
+}
+
+//returns a new IntSeq consisting of all of the elements of s except for the first one
+func (s *IntSeq) Rest() Sequence {
+	s2 := (*s)[1:]
+	return Sequence{&s2}
+}
+
+func (s *IntSeq) Len() int {return len(*s)}
+
+func (s *IntSeq) Describe() Description {return Description{"IntSeq", map[string]interface{}{"len": len(*s)}, nil}}
+
+//returns a new IntSeq of the results of applying f to the elements of s
+func (s *IntSeq) Map(f func(n int) int) *IntSeq {
+	result := make(IntSeq, len(*s))
+	for i, n := range *s {result[i] = f(n)}
+	return &result
+}
+
+//returns a new IntSeq of the elements of s for which f returns true
+func (s *IntSeq) Filter(f func(n int) bool) *IntSeq {
+	result := make(IntSeq, 0, len(*s))
+	for _, n := range *s {
+		if f(n) {result = append(result, n)}
+	}
+	return &result
+}
+
+//returns the result of applying f to its previous value and each element of s in succession, starting with init
+func (s *IntSeq) Fold(init int, f func(acc, n int) int) int {
+	for _, n := range *s {init = f(init, n)}
+	return init
+}
+
+//returns s as a Sequence
+func (s *IntSeq) Sequence() Sequence {return Sequence{s}}
+
+//a sequential sequence of float64s stored unboxed; its own Map, Filter, and Fold work on float64s directly and elements are only boxed when they leave through Find
+type Float64Seq []float64
+
+//returns a new Float64Seq sequence over floats, which is not copied
+func FromFloat64s(floats []float64) Sequence {
+	s := Float64Seq(floats)
+	return Sequence{&s}
+}
+
+//Float64Seqs are not concurrent; return false
+func (s *Float64Seq) IsConcurrent() bool {return false}
+
+//returns the first item for which f returns true or nil if none is found
+func (s *Float64Seq) Find(f func(el El)bool) El {
+	for _, x := range *s {
+		if f(x) {return x}
+	}
+	return nil
+}
+
+//returns a new Float64Seq consisting of all of the elements of s except for the first one
+func (s *Float64Seq) Rest() Sequence {
+	s2 := (*s)[1:]
+	return Sequence{&s2}
+}
+
+func (s *Float64Seq) Len() int {return len(*s)}
+
+func (s *Float64Seq) Describe() Description {return Description{"Float64Seq", map[string]interface{}{"len": len(*s)}, nil}}
+
+//returns a new Float64Seq of the results of applying f to the elements of s
+func (s *Float64Seq) Map(f func(x float64) float64) *Float64Seq {
+	result := make(Float64Seq, len(*s))
+	for i, x := range *s {result[i] = f(x)}
+	return &result
+}
+
+//returns a new Float64Seq of the elements of s for which f returns true
+func (s *Float64Seq) Filter(f func(x float64) bool) *Float64Seq {
+	result := make(Float64Seq, 0, len(*s))
+	for _, x := range *s {
+		if f(x) {result = append(result, x)}
+	}
+	return &result
+}
+
+//returns the result of applying f to its previous value and each element of s in succession, starting with init
+func (s *Float64Seq) Fold(init float64, f func(acc, x float64) float64) float64 {
+	for _, x := range *s {init = f(init, x)}
+	return init
+}
+
+//returns s as a Sequence
+func (s *Float64Seq) Sequence() Sequence {return Sequence{s}}
@@ -0,0 +1,72 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "fmt"
+import "io"
+import "os"
+
+//a Seq that calls an observer with each element as it passes through a traversal
+type tapSeq struct {
+	source Sequence
+	observe func(el El)
+}
+
+func (s *tapSeq) IsConcurrent() bool {return s.source.IsConcurrent()}
+func (s *tapSeq) Find(f func(el El)bool) El {
+	return s.source.Find(func(el El)bool{
+		s.observe(el)
+		return f(el)
+	})
+}
+func (s *tapSeq) Rest() Sequence {return s.source.Rest().Tap(s.observe)}
+//returns the length of the source without observing its elements
+func (s *tapSeq) Len() int {return s.source.Len()}
+func (s *tapSeq) Describe() Description {
+	src := s.source.Describe()
+	return Description{"Tap", nil, &src}
+}
+
+//returns a sequence of the same type as s with the same elements, which calls f with each element as a traversal reaches it; a sequential s is not copied, so Tap is a cheap way to watch a pipeline while debugging
+func (s Sequence) Tap(f func(el El)) Sequence {return Sequence{&tapSeq{s, f}}}
+
+//where Trace writes when it is not given a writer
+var TraceWriter io.Writer = os.Stderr
+
+//a Seq that reports each traversal of its source to a writer
+type traceSeq struct {
+	source Sequence
+	label string
+	w io.Writer
+}
+
+func (s *traceSeq) IsConcurrent() bool {return s.source.IsConcurrent()}
+func (s *traceSeq) Find(f func(el El)bool) El {
+	clock := s.source.Clock()
+	start, count, stopped := clock.Now(), 0, false
+	result := s.source.Find(func(el El)bool{
+		count++
+		stopped = f(el)
+		return stopped
+	})
+	how := "finished"
+	if stopped {how = "stopped"}
+	fmt.Fprintf(s.w, "%s: %s after %d elements in %.3fms: %v\n", s.label, how, count, float64(clock.Now() - start) / 1e6, s.source.Describe())
+	return result
+}
+func (s *traceSeq) Rest() Sequence {return Sequence{&traceSeq{s.source.Rest(), s.label, s.w}}}
+//returns the length of the source without tracing
+func (s *traceSeq) Len() int {return s.source.Len()}
+func (s *traceSeq) Describe() Description {
+	src := s.source.Describe()
+	return Description{"Trace", map[string]interface{}{"label": s.label}, &src}
+}
+
+//returns a sequence of the same type as s with the same elements, which writes a line to writerOpt[0], or TraceWriter if none is given, each time a traversal of it finishes or stops.  The line holds label, how many elements went by, the time taken according to s's Clock, and the description of s, so tracing each stage of a pipeline shows where time goes and where elements are dropped
+func (s Sequence) Trace(label string, writerOpt... io.Writer) Sequence {
+	w := TraceWriter
+	if len(writerOpt) > 0 && writerOpt[0] != nil {w = writerOpt[0]}
+	return Sequence{&traceSeq{s, label, w}}
+}
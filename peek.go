@@ -0,0 +1,117 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "runtime"
+import "sync"
+
+//a single traversal of a sequence, consumed a piece at a time, with room to push back the one element Peek looks at
+type peekSeq struct {
+	lock sync.Mutex
+	source Sequence
+	c SeqChan
+	head El
+	hasHead, done bool
+}
+
+//returns a new ConcurrentSeq which is one ongoing traversal of s: each traversal of the result, including Find, Len, and Rest, continues where the previous one stopped, and Peek looks at the next element without consuming it.  s is not started until the first element is needed.  The result owns s's producer: Close stops it, and so does garbage collection of a result that is abandoned before s ends
+func (s Sequence) Peekable() Sequence {
+	p := &peekSeq{source: s}
+	runtime.SetFinalizer(p, (*peekSeq).Close)
+	return Sequence{p}.stage("Peekable", s)
+}
+
+//returns the first element of s and whether s had any elements.  On a sequence from Peekable the element is pushed back, so the next traversal still begins with it; on any other sequence this is a traversal that stops after the first element
+func (s Sequence) Peek() (El, bool) {
+	if p, ok := s.base().Seq.(*peekSeq); ok {return p.peek()}
+	var head El
+	found := false
+	s.Find(func(el El)bool{
+		head, found = el, true
+		return true
+	})
+	return head, found
+}
+
+//reads the next element from the source into the pushback buffer if it is empty; must be called with the lock held
+func (s *peekSeq) fill() {
+	if s.hasHead || s.done {return}
+	if s.c == nil {s.c = s.source.channel()}
+	el := <- s.c
+	if closed(s.c) {
+		s.done = true
+		return
+	}
+	s.head, s.hasHead = el, true
+}
+
+func (s *peekSeq) peek() (El, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.fill()
+	return s.head, s.hasHead
+}
+
+//removes and returns the next element, and whether there was one
+func (s *peekSeq) next() (El, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.fill()
+	if !s.hasHead {return nil, false}
+	el := s.head
+	s.head, s.hasHead = nil, false
+	return el, true
+}
+
+//sends the rest of the stream to c, consuming each element only after it is sent, so when c's consumer closes it, the element it did not take stays at the head for the next traversal
+func (s *peekSeq) output(c SeqChan) {
+	for el, ok := s.peek(); ok; el, ok = s.peek() {
+		c <- el
+		if closed(c) {return}
+		s.next()
+	}
+}
+
+//peekSeqs are concurrent; return true
+func (s *peekSeq) IsConcurrent() bool {return true}
+
+//consumes elements until f returns true for one, returning it, or until the source ends, returning nil
+func (s *peekSeq) Find(f func(el El)bool) El {
+	for el, ok := s.next(); ok; el, ok = s.next() {
+		if f(el) {return el}
+	}
+	return nil
+}
+
+//consumes the next element and returns s
+func (s *peekSeq) Rest() Sequence {
+	s.next()
+	return Sequence{s}
+}
+
+//consumes the rest of the elements and returns how many there were
+func (s *peekSeq) Len() int {
+	count := 0
+	for _, ok := s.next(); ok; _, ok = s.next() {count++}
+	return count
+}
+
+//ends the traversal, stopping the producer if it has started and not finished; the sequence is empty afterwards
+func (s *peekSeq) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.c != nil && !s.done {close(s.c)}
+	s.head, s.hasHead, s.done = nil, false, true
+}
+
+//releases what s holds, such as the producer of a Peekable sequence or the file of a SpillSeq; sequences that hold nothing are unaffected
+func (s Sequence) Close() {
+	for _, layer := range s.layers() {
+		if c, ok := layer.(interface{Close()}); ok {
+			c.Close()
+			return
+		}
+	}
+}
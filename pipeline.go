@@ -0,0 +1,79 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "sync"
+
+//a handle for shutting down a running concurrent pipeline.  Drain stops the pipeline's source but lets the elements already in flight finish and be emitted; Cancel also stops emitting, dropping whatever is in flight
+type Pipeline struct {
+	stopOnce, cancelOnce sync.Once
+	stop, cancel chan bool
+}
+
+//returns a new Pipeline handle
+func NewPipeline() *Pipeline {return &Pipeline{stop: make(chan bool), cancel: make(chan bool)}}
+
+//stops taking input; elements already taken finish every stage and are emitted before the pipeline ends
+func (p *Pipeline) Drain() {p.stopOnce.Do(func(){close(p.stop)})}
+
+//stops taking input and stops emitting output immediately, dropping in-flight elements
+func (p *Pipeline) Cancel() {
+	p.Drain()
+	p.cancelOnce.Do(func(){close(p.cancel)})
+}
+
+//returns whether Drain or Cancel has been called
+func (p *Pipeline) Stopped() bool {return isDone(p.stop)}
+
+//returns whether Cancel has been called
+func (p *Pipeline) Canceled() bool {return isDone(p.cancel)}
+
+//returns whether the done channel has been closed
+func isDone(done chan bool) bool {
+	select {
+	case <- done: return true
+	default:
+	}
+	return false
+}
+
+//returns a new ConcurrentSeq with the elements of s which ends early once p is drained or canceled
+func (p *Pipeline) Source(s Sequence) Sequence {
+	return Gen(func(c SeqChan){
+		input := s.channel()
+		for {
+			select {
+			case el := <- input:
+				if closed(input) {return}
+				c <- el
+			case <- p.stop:
+				close(input)
+				return
+			}
+		}
+	}).stage("PipelineSource", s)
+}
+
+//returns a new ConcurrentSeq with the elements of s which ends early once p is canceled
+func (p *Pipeline) Sink(s Sequence) Sequence {
+	return Gen(func(c SeqChan){
+		input := s.channel()
+		for {
+			select {
+			case el := <- input:
+				if closed(input) {return}
+				c <- el
+			case <- p.cancel:
+				close(input)
+				return
+			}
+		}
+	}).stage("PipelineSink", s)
+}
+
+//returns the sequence stages builds from source, with source guarded by p.Source and the result guarded by p.Sink, so p can drain or cancel it
+func (p *Pipeline) Run(source Sequence, stages func(s Sequence) Sequence) Sequence {
+	return p.Sink(stages(p.Source(source)))
+}
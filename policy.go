@@ -0,0 +1,81 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "fmt"
+import "os"
+
+//determines how a sequence reports misuse, such as asking FirstN for more elements than there are
+type Policy int
+
+const (
+	//panic immediately with a *SeqError describing the problem
+	Strict Policy = iota
+	//carry on with zero values; the ...Err variants of the operations still return the *SeqError
+	Lenient
+)
+
+//the policy for sequences that have not been given one with WithPolicy; Lenient keeps the behavior from before policies existed, where short sequences were padded with nils
+var DefaultPolicy = Lenient
+
+func (p Policy) String() string {
+	switch p {
+	case Strict: return "Strict"
+	case Lenient: return "Lenient"
+	}
+	return fmt.Sprintf("Policy(%d)", int(p))
+}
+
+//describes misuse of a sequence operation
+type SeqError struct {
+	Op string
+	Msg string
+}
+
+func (e *SeqError) String() string {return "seq: " + e.Op + ": " + e.Msg}
+
+func seqError(op string, format string, args... interface{}) *SeqError {
+	return &SeqError{op, fmt.Sprintf(format, args...)}
+}
+
+type policySeq struct {
+	Seq
+	policy Policy
+}
+
+func (s *policySeq) unwrap() Seq {return s.Seq}
+func (s *policySeq) Rest() Sequence {return s.Seq.Rest().WithPolicy(s.policy)}
+func (s *policySeq) Describe() Description {
+	src := Sequence{s.Seq}.Describe()
+	return Description{"WithPolicy", map[string]interface{}{"policy": s.policy}, &src}
+}
+
+//returns a sequence with the same elements as s which reports misuse according to p
+func (s Sequence) WithPolicy(p Policy) Sequence {return Sequence{&policySeq{s.Seq, p}}}
+
+//returns the policy s uses to report misuse
+func (s Sequence) Policy() Policy {
+	if p, ok := s.policyOf(); ok {return p}
+	return DefaultPolicy
+}
+
+//returns the policy s was given with WithPolicy and whether it was given one
+func (s Sequence) policyOf() (Policy, bool) {
+	for _, layer := range s.layers() {
+		if p, ok := layer.(*policySeq); ok {return p.policy, true}
+	}
+	return DefaultPolicy, false
+}
+
+//returns result with the policy source was given with WithPolicy, if any, so a policy set at the start of a pipeline applies to every stage
+func (result Sequence) inheritPolicy(source Sequence) Sequence {
+	if p, ok := source.policyOf(); ok {return result.WithPolicy(p)}
+	return result
+}
+
+//reports err according to s's policy: Strict panics with it and Lenient ignores it
+func (s Sequence) check(err os.Error) {
+	if err != nil && s.Policy() == Strict {panic(err)}
+}
@@ -0,0 +1,121 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "fmt"
+import "io"
+import "json"
+import "strconv"
+import "strings"
+
+//selects the notation Pretty prints in
+type PrettyMode int
+
+const (
+	//Pretty's own [a, b, c] notation
+	PrettyBrackets PrettyMode = iota
+	//JSON arrays; names print as strings
+	PrettyJSON
+	//s-expressions; names print as symbols
+	PrettySexp
+)
+
+//the notation prettyStructured prints
+type prettyStyle struct {
+	open, close, sep, cycle string
+	atom func(v interface{}) string
+	name func(name string) string
+}
+
+var jsonStyle = prettyStyle{"[", "]", ", ", `"<cycle>"`, jsonAtom, strconv.Quote}
+var sexpStyle = prettyStyle{"(", ")", " ", "#cycle", sexpAtom, func(name string) string {return name}}
+
+func jsonAtom(v interface{}) string {
+	if bytes, err := json.Marshal(v); err == nil {return string(bytes)}
+	bytes, _ := json.Marshal(fmt.Sprint(v))
+	return string(bytes)
+}
+
+func sexpAtom(v interface{}) string {
+	switch a := v.(type) {
+	case nil: return "nil"
+	case string: return strconv.Quote(a)
+	case bool:
+		if a {return "#t"}
+		return "#f"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64: return fmt.Sprint(a)
+	}
+	return strconv.Quote(fmt.Sprint(v))
+}
+
+//prints s in style, putting each unnamed nested sequence on its own line indented by level
+func prettyStructured(s interface{}, level int, style *prettyStyle, names map[interface{}]string, w io.Writer, path map[interface{}]bool) {
+	if name, has := getName(names, s); has {
+		io.WriteString(w, style.name(name))
+		return
+	}
+	switch arg := s.(type) {
+	case Sequence: prettyStructured(arg.Seq, level, style, names, w, path)
+	case Seq:
+		if hashable(arg) {
+			if path[arg] {
+				io.WriteString(w, style.cycle)
+				return
+			}
+			path[arg] = true
+			defer func(){path[arg] = false, false}()
+		}
+		io.WriteString(w, style.open)
+		first, nested := true, false
+		Sequence{arg}.Do(func(v El){
+			sub, isSeq := v.(Sequence)
+			isSeq = isSeq && !hasName(names, v)
+			if !first && isSeq {
+				io.WriteString(w, strings.TrimSpace(style.sep))
+			} else if !first {
+				io.WriteString(w, style.sep)
+			}
+			first = false
+			if isSeq {
+				nested = true
+				fmt.Fprintf(w, "\n%*s", level + 4, "")
+				prettyStructured(sub, level + 4, style, names, w, path)
+			} else {
+				prettyStructured(v, level, style, names, w, path)
+			}
+		})
+		if nested {fmt.Fprintf(w, "\n%*s", level, "")}
+		io.WriteString(w, style.close)
+	default: io.WriteString(w, style.atom(arg))
+	}
+}
+
+//ANSI terminal escape sequences for Pretty to colorize its bracket notation with.  Brackets cycle through Brackets by nesting depth, and each name always gets the same color from Names
+type PrettyColors struct {
+	Brackets []string
+	Names []string
+	Reset string
+}
+
+//a palette for terminals with the standard ANSI colors; pass it to Pretty to colorize
+var DefaultColors = &PrettyColors{
+	[]string{"\x1b[36m", "\x1b[33m", "\x1b[35m", "\x1b[32m"},
+	[]string{"\x1b[1;31m", "\x1b[1;32m", "\x1b[1;34m", "\x1b[1;35m", "\x1b[1;36m", "\x1b[1;33m"},
+	"\x1b[0m",
+}
+
+//returns bracket colored for depth; a nil *PrettyColors leaves text uncolored
+func (c *PrettyColors) bracket(bracket string, depth int) string {
+	if c == nil || len(c.Brackets) == 0 {return bracket}
+	return c.Brackets[depth % len(c.Brackets)] + bracket + c.Reset
+}
+
+//returns name colored by a hash of its text; a nil *PrettyColors leaves text uncolored
+func (c *PrettyColors) name(name string) string {
+	if c == nil || len(c.Names) == 0 {return name}
+	hash := uint(0)
+	for i := 0; i < len(name); i++ {hash = hash * 31 + uint(name[i])}
+	return c.Names[hash % uint(len(c.Names))] + name + c.Reset
+}
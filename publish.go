@@ -0,0 +1,134 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "sync"
+
+//one traversal of a Broadcast's subscription
+type subscriber struct {
+	inbox chan interface{}
+	quit chan bool
+}
+
+//A Broadcast shares one live traversal of a sequence among any number of subscribers.
+//It keeps the most recent elements so late subscribers can catch up, and it sends each element to every subscriber, waiting for slow subscribers, so the slowest one sets the pace
+type Broadcast struct {
+	lock sync.Mutex
+	replay int
+	recent []interface{}
+	subscribers map[*subscriber]bool
+	done bool
+}
+
+//starts traversing s in the background and returns a Broadcast of its elements, which keeps the last replay elements for late subscribers, or every element if replay is negative.  Elements that arrive while there are no subscribers are only kept for replay
+func (s Sequence) Publish(replay int) *Broadcast {
+	size := replay
+	if size < 0 {size = 8}
+	b := &Broadcast{replay: replay, recent: make([]interface{}, 0, size), subscribers: map[*subscriber]bool{}}
+	go func() {
+		s.Do(b.send)
+		b.finish()
+	}()
+	return b
+}
+
+//records el for replay and sends it to every current subscriber
+func (b *Broadcast) send(el El) {
+	b.lock.Lock()
+	if b.replay != 0 {
+		if len(b.recent) == b.replay {b.recent = append(b.recent[:0], b.recent[1:]...)}
+		b.recent = append(b.recent, el)
+	}
+	subs := make([]*subscriber, 0, len(b.subscribers))
+	for sub := range b.subscribers {subs = append(subs, sub)}
+	b.lock.Unlock()
+	for _, sub := range subs {
+		select {
+		case sub.inbox <- el:
+		case <- sub.quit:
+		}
+	}
+}
+
+//ends every subscription after the source sequence ends
+func (b *Broadcast) finish() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.done = true
+	for sub := range b.subscribers {close(sub.inbox)}
+	b.subscribers = nil
+}
+
+//returns a new ConcurrentSeq which, each time it is traversed, subscribes to b: it has the elements b is keeping for replay followed by each element b receives until b's source ends.  Abandoning the traversal ends the subscription
+func (b *Broadcast) Subscribe() Sequence {
+	return Gen(func(c SeqChan){
+		b.lock.Lock()
+		recent := append([]interface{}(nil), b.recent...)
+		var sub *subscriber
+		if !b.done {
+			sub = &subscriber{make(chan interface{}), make(chan bool)}
+			b.subscribers[sub] = true
+		}
+		b.lock.Unlock()
+		for _, el := range recent {
+			c <- el
+			if closed(c) {
+				b.unsubscribe(sub)
+				return
+			}
+		}
+		if sub == nil {return}
+		for el := <- sub.inbox; !closed(sub.inbox); el = <- sub.inbox {
+			c <- el
+			if closed(c) {
+				b.unsubscribe(sub)
+				return
+			}
+		}
+	}).sourceStage("Broadcast", "replay", b.replay)
+}
+
+//removes sub from b and releases any send to it that is waiting
+func (b *Broadcast) unsubscribe(sub *subscriber) {
+	if sub == nil {return}
+	b.lock.Lock()
+	if !b.done {b.subscribers[sub] = false, false}
+	b.lock.Unlock()
+	close(sub.quit)
+}
+
+//returns the number of current subscribers
+func (b *Broadcast) Subscribers() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.subscribers)
+}
+
+//a ConcurrentSeq whose traversals share one running producer
+type hotSeq struct {
+	Seq
+}
+
+func (s *hotSeq) unwrap() Seq {return s.Seq}
+
+//returns a new hot sequence of the elements of s.  Sequences are normally cold: each traversal of a ConcurrentSeq runs its generator again from the start, so calling Len and then Do generates everything twice.  A hot sequence instead starts one traversal of s now, and each traversal of the result joins it where it is, seeing only the elements that arrive after it starts, the way Publish's subscribers do.  If s is already hot, it is returned
+func (s Sequence) Hot() Sequence {
+	if s.IsHot() {return s}
+	return Sequence{&hotSeq{s.Publish(0).Subscribe().Seq}}
+}
+
+//returns whether s is hot, with traversals that share a running producer rather than starting their own; see Hot
+func (s Sequence) IsHot() bool {
+	for _, layer := range s.layers() {
+		if _, ok := layer.(*hotSeq); ok {return true}
+	}
+	return false
+}
+
+//returns a cold sequence of the elements of s, which each traversal sees from the start.  A hot s cannot be restarted, so it is joined now and every element from then on is kept for replay, which holds the whole stream in memory.  If s is already cold, it is returned
+func (s Sequence) Cold() Sequence {
+	if !s.IsHot() {return s}
+	return s.Publish(-1).Subscribe()
+}
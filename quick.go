@@ -0,0 +1,83 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "rand"
+import "reflect"
+
+//returns a function for testing/quick's Config.Values that fills the arguments of each call from the next element of s.  An element that is a Sequence supplies one argument per item; any other element supplies every argument.  When s runs out it starts over from the beginning.  An empty Sequence element or a nil value cannot fill an argument, so either panics with a descriptive error
+func (s Sequence) AsQuickValues() func(args []reflect.Value, r *rand.Rand) {
+	items := s.ToSlice()
+	next := 0
+	return func(args []reflect.Value, r *rand.Rand) {
+		if len(items) == 0 {panic(seqError("AsQuickValues", "no values in an empty sequence"))}
+		index := next % len(items)
+		vals := []interface{}{items[index]}
+		next++
+		if sub, ok := vals[0].(Sequence); ok {vals = sub.ToSlice()}
+		if len(vals) == 0 && len(args) > 0 {panic(seqError("AsQuickValues", "element %d is an empty sequence, so it has no values for %d arguments", index, len(args)))}
+		for i := range args {
+			if vals[i % len(vals)] == nil {panic(seqError("AsQuickValues", "element %d supplies nil for argument %d, which has no type to make a Value from", index, i))}
+			args[i] = reflect.NewValue(vals[i % len(vals)])
+		}
+	}
+}
+
+//deterministically decodes values from bytes a fuzzer provides; once the bytes run out every value is zero
+type FuzzSource struct {
+	data []byte
+	pos int
+}
+
+//returns a new FuzzSource reading data
+func NewFuzzSource(data []byte) *FuzzSource {return &FuzzSource{data, 0}}
+
+//returns whether all of the bytes have been used
+func (f *FuzzSource) Exhausted() bool {return f.pos >= len(f.data)}
+
+//returns the next byte
+func (f *FuzzSource) Byte() byte {
+	if f.Exhausted() {return 0}
+	f.pos++
+	return f.data[f.pos - 1]
+}
+
+//returns the next bool
+func (f *FuzzSource) Bool() bool {return f.Byte() & 1 == 1}
+
+//returns the next non-negative int, using up to 4 bytes
+func (f *FuzzSource) Int() int {
+	n := 0
+	for i := 0; i < 4; i++ {n = n << 8 | int(f.Byte())}
+	return n & 0x7fffffff
+}
+
+//returns the next int in [0, n), or 0 if n <= 0
+func (f *FuzzSource) Intn(n int) int {
+	if n <= 0 {return 0}
+	return f.Int() % n
+}
+
+//returns the next n bytes, or fewer if the data runs out
+func (f *FuzzSource) Bytes(n int) []byte {
+	end := f.pos + n
+	if end > len(f.data) {end = len(f.data)}
+	if end < f.pos {end = f.pos}
+	result := f.data[f.pos:end]
+	f.pos = end
+	return result
+}
+
+//returns a new SequentialSeq of the elements gen decodes from data, calling gen until the data is exhausted or gen stops consuming it; the same data always produces the same sequence
+func FromFuzz(data []byte, gen func(src *FuzzSource) El) Sequence {
+	src := NewFuzzSource(data)
+	slice := make([]interface{}, 0, 8)
+	for !src.Exhausted() {
+		pos := src.pos
+		slice = append(slice, gen(src))
+		if src.pos == pos {break}
+	}
+	return Sequence{(*SequentialSeq)(&slice)}
+}
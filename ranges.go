@@ -0,0 +1,38 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+//calls f with a [start, end] sequence for each run of consecutive ints in s
+func (s Sequence) eachRange(f func(r Sequence)) {
+	start, end, started := 0, 0, false
+	s.Do(func(el El){
+		i := el.(int)
+		switch {
+		case !started: start, end, started = i, i, true
+		case i == end || i == end + 1: end = i
+		default:
+			f(From(start, end))
+			start, end = i, i
+		}
+	})
+	if started {f(From(start, end))}
+}
+
+//returns a new sequence of the same type as s which compacts the sorted ints in s into [start, end] sequences covering each run of consecutive values, ends included.  Duplicates are absorbed; if s is not sorted the ranges still cover it, just not as compactly
+func (s Sequence) ToRanges() Sequence {
+	return s.produce("ToRanges", func(emit func(el El)){
+		s.eachRange(func(r Sequence){emit(r)})
+	})
+}
+
+//returns a new sequence of the same type as ranges consisting of every int covered by the [start, end] sequences in ranges; the inverse of ToRanges
+func FromRanges(ranges Sequence) Sequence {
+	return ranges.produce("FromRanges", func(emit func(el El)){
+		ranges.Do(func(r El){
+			start, end := r.(Sequence).First2()
+			for i := start.(int); i <= end.(int); i++ {emit(i)}
+		})
+	})
+}
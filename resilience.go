@@ -0,0 +1,105 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "os"
+import "sync"
+
+//returns a backoff for RetryMap which waits initial nanoseconds after the first failure and doubles the wait after each later one, up to max
+func ExponentialBackoff(initial, max int64) func(try int) int64 {
+	return func(try int) int64 {
+		wait := initial
+		for i := 1; i < try && wait < max; i++ {wait *= 2}
+		if wait > max {wait = max}
+		return wait
+	}
+}
+
+//returns a new ConcurrentSeq of Results of applying f to the elements of s concurrently, like CMap, calling f up to attempts times for each element until it succeeds.  After failed try number n (starting at 1), the worker waits backoff(n) nanoseconds, according to s's Clock, before trying again, and keeps its place in CMap's concurrency limit while it waits; backoff may be nil to retry immediately.  An element whose tries all fail becomes a Result holding the element and the last error
+func (s Sequence) RetryMap(f func(el El) (El, os.Error), attempts int, backoff func(try int) int64, sizePowerOpt... uint) Sequence {
+	if attempts < 1 {attempts = 1}
+	clock := s.Clock()
+	return s.cmap("RetryMap", true, func(el El) El {
+		var err os.Error
+		for try := 1; ; try++ {
+			var value El
+			if value, err = f(el); err == nil {return Result{value, nil}}
+			if try == attempts {break}
+			if backoff != nil {
+				if wait := backoff(try); wait > 0 {<- clock.After(wait)}
+			}
+		}
+		return Result{el, err}
+	}, sizePowerOpt...)
+}
+
+//settings for BreakerMap's circuit breaker: it opens when at least Threshold of the last Window calls have failed, and stays open for CoolDown nanoseconds.  While it is open, elements fail immediately with ErrBreakerOpen if FastFail is true, or else wait for it to close, which pauses intake once every worker is waiting
+type Breaker struct {
+	Threshold float64
+	Window int
+	CoolDown int64
+	FastFail bool
+}
+
+//the Breaker settings BreakerMap uses for zero fields
+var DefaultBreaker = Breaker{0.5, 20, 1e9, false}
+
+//the error in the Results of elements a fast-failing breaker rejects
+var ErrBreakerOpen os.Error = &SeqError{"BreakerMap", "circuit breaker open"}
+
+//the state of one BreakerMap's breaker, shared by its workers
+type breakerState struct {
+	lock sync.Mutex
+	settings Breaker
+	clock Clock
+	//the outcomes of the most recent calls, true for failures, in a ring
+	recent []bool
+	next, count, failures int
+	openUntil int64
+}
+
+//returns how long the breaker will stay open, or 0 if it is closed
+func (b *breakerState) open() int64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if now := b.clock.Now(); now < b.openUntil {return b.openUntil - now}
+	return 0
+}
+
+//records the outcome of a call, opening the breaker and starting a fresh window if there have been too many failures
+func (b *breakerState) record(failed bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.count == len(b.recent) {
+		if b.recent[b.next] {b.failures--}
+	} else {
+		b.count++
+	}
+	b.recent[b.next] = failed
+	if failed {b.failures++}
+	b.next = (b.next + 1) % len(b.recent)
+	if b.count == len(b.recent) && float64(b.failures) >= b.settings.Threshold * float64(b.count) {
+		b.openUntil = b.clock.Now() + b.settings.CoolDown
+		b.next, b.count, b.failures = 0, 0, 0
+	}
+}
+
+//returns a new ConcurrentSeq of Results of applying f to the elements of s concurrently, like CMap, behind a circuit breaker (see Breaker) which protects whatever f calls from being hammered while it is failing.  Cool-downs are timed with s's Clock
+func (s Sequence) BreakerMap(f func(el El) (El, os.Error), b Breaker, sizePowerOpt... uint) Sequence {
+	if b.Threshold <= 0 {b.Threshold = DefaultBreaker.Threshold}
+	if b.Window <= 0 {b.Window = DefaultBreaker.Window}
+	if b.CoolDown <= 0 {b.CoolDown = DefaultBreaker.CoolDown}
+	state := &breakerState{settings: b, clock: s.Clock(), recent: make([]bool, b.Window)}
+	return s.cmap("BreakerMap", true, func(el El) El {
+		for wait := state.open(); wait > 0; wait = state.open() {
+			if b.FastFail {return Result{el, ErrBreakerOpen}}
+			<- state.clock.After(wait)
+		}
+		value, err := f(el)
+		state.record(err != nil)
+		if err != nil {return Result{el, err}}
+		return Result{value, nil}
+	}, sizePowerOpt...)
+}
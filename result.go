@@ -0,0 +1,63 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "os"
+
+//an element carrying either a value or the error from computing it, so pipelines can pass bad records along instead of stopping.  When Err is not nil, Value is the element that failed.  The Result helpers treat elements that are not Results as successful values
+type Result struct {
+	Value El
+	Err os.Error
+}
+
+//returns el as a Result
+func resultOf(el El) Result {
+	if r, ok := el.(Result); ok {return r}
+	return Result{el, nil}
+}
+
+//returns a new sequence of the same type as s of Results of applying f to the values of s; failed Results pass through untouched, so a chain of MapRs reports the first failure of each element
+func (s Sequence) MapR(f func(el El) (El, os.Error)) Sequence {
+	return s.Map(func(el El) El {
+		r := resultOf(el)
+		if r.Err != nil {return r}
+		value, err := f(r.Value)
+		if err != nil {return Result{r.Value, err}}
+		return Result{value, nil}
+	})
+}
+
+//returns a new sequence of the same type as s of its successful Results
+func (s Sequence) FilterOK() Sequence {
+	return s.Filter(func(el El) bool {return resultOf(el).Err == nil})
+}
+
+//returns a new sequence of the same type as s of the values of its successful Results
+func (s Sequence) Values() Sequence {
+	return s.produce("Values", func(emit func(el El)){
+		s.Do(func(el El){
+			if r := resultOf(el); r.Err == nil {emit(r.Value)}
+		})
+	})
+}
+
+//returns an *ElError, holding the element that failed, for each failed Result in s, in order
+func (s Sequence) Errors() []os.Error {
+	errs := []os.Error{}
+	s.Do(func(el El){
+		if r := resultOf(el); r.Err != nil {errs = append(errs, &ElError{r.Value, r.Err})}
+	})
+	return errs
+}
+
+//returns an *ElError for the first failed Result in s, or nil if there is none; a concurrent s is only read up to that point
+func (s Sequence) FirstErr() os.Error {
+	var err os.Error
+	s.While(func(el El)bool{
+		if r := resultOf(el); r.Err != nil {err = &ElError{r.Value, r.Err}}
+		return err == nil
+	})
+	return err
+}
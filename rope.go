@@ -0,0 +1,169 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+//the most elements a rope leaf holds
+const ropeLeafMax = 32
+
+//a node of a persistent, height-balanced rope: either a leaf holding elements or a branch joining two non-nil nodes.  Nodes and their leaf slices are never modified, so ropes share them freely
+type ropeNode struct {
+	left, right *ropeNode
+	leaf []interface{}
+	length, height int
+}
+
+func (n *ropeNode) len() int {
+	if n == nil {return 0}
+	return n.length
+}
+
+func (n *ropeNode) depth() int {
+	if n == nil {return 0}
+	return n.height
+}
+
+func ropeLeaf(items []interface{}) *ropeNode {
+	if len(items) == 0 {return nil}
+	return &ropeNode{leaf: items, length: len(items), height: 1}
+}
+
+func ropeBranch(l, r *ropeNode) *ropeNode {
+	height := l.depth()
+	if r.depth() > height {height = r.depth()}
+	return &ropeNode{left: l, right: r, length: l.len() + r.len(), height: height + 1}
+}
+
+//rotates n, a branch, if its sides differ in height by more than one
+func ropeBalance(n *ropeNode) *ropeNode {
+	switch {
+	case n.left.depth() > n.right.depth() + 1:
+		l := n.left
+		if l.left.depth() < l.right.depth() {l = ropeBranch(ropeBranch(l.left, l.right.left), l.right.right)}
+		return ropeBranch(l.left, ropeBranch(l.right, n.right))
+	case n.right.depth() > n.left.depth() + 1:
+		r := n.right
+		if r.right.depth() < r.left.depth() {r = ropeBranch(r.left.left, ropeBranch(r.left.right, r.right))}
+		return ropeBranch(ropeBranch(n.left, r.left), r.right)
+	}
+	return n
+}
+
+//returns a balanced rope of l followed by r in O(log n)
+func ropeJoin(l, r *ropeNode) *ropeNode {
+	switch {
+	case l == nil: return r
+	case r == nil: return l
+	case l.leaf != nil && r.leaf != nil && l.length + r.length <= ropeLeafMax:
+		items := make([]interface{}, 0, l.length + r.length)
+		return ropeLeaf(append(append(items, l.leaf...), r.leaf...))
+	case l.height > r.height + 1: return ropeBalance(ropeBranch(l.left, ropeJoin(l.right, r)))
+	case r.height > l.height + 1: return ropeBalance(ropeBranch(ropeJoin(l, r.left), r.right))
+	}
+	return ropeBranch(l, r)
+}
+
+//returns ropes of the first k elements of n and the rest
+func ropeSplit(n *ropeNode, k int) (*ropeNode, *ropeNode) {
+	switch {
+	case n == nil: return nil, nil
+	case k <= 0: return nil, n
+	case k >= n.length: return n, nil
+	case n.leaf != nil: return ropeLeaf(n.leaf[:k]), ropeLeaf(n.leaf[k:])
+	case k < n.left.length:
+		a, b := ropeSplit(n.left, k)
+		return a, ropeJoin(b, n.right)
+	}
+	a, b := ropeSplit(n.right, k - n.left.length)
+	return ropeJoin(n.left, a), b
+}
+
+//returns a balanced rope of a copy of items
+func ropeBuild(items []interface{}) *ropeNode {
+	if len(items) <= ropeLeafMax {
+		leaf := make([]interface{}, len(items))
+		copy(leaf, items)
+		return ropeLeaf(leaf)
+	}
+	mid := (len(items) / ropeLeafMax + 1) / 2 * ropeLeafMax
+	return ropeBranch(ropeBuild(items[:mid]), ropeBuild(items[mid:]))
+}
+
+//returns the first element of n for which f returns true, and whether there was one
+func (n *ropeNode) find(f func(el El)bool) (El, bool) {
+	switch {
+	case n == nil: return nil, false
+	case n.leaf != nil:
+		for _, el := range n.leaf {
+			if f(el) {return el, true}
+		}
+		return nil, false
+	}
+	if el, found := n.left.find(f); found {return el, true}
+	return n.right.find(f)
+}
+
+//a sequence backed by a balanced tree, with O(log n) Append, Prepend, Slice and Nth.  Ropes are persistent: those operations return new ropes which share structure with the old ones
+type RopeSeq struct {
+	root *ropeNode
+}
+
+//returns a new RopeSeq consisting of els
+func Rope(els... interface{}) Sequence {return Sequence{&RopeSeq{ropeBuild(els)}}}
+
+//returns s as a RopeSeq, copying it into one if necessary
+func (s Sequence) ToRope() Sequence {
+	if _, ok := s.base().Seq.(*RopeSeq); ok {return s}
+	return Sequence{&RopeSeq{ropeBuild(s.ToSlice())}}
+}
+
+func ropeOf(s Sequence) *ropeNode {
+	if r, ok := s.base().Seq.(*RopeSeq); ok {return r.root}
+	return ropeBuild(s.ToSlice())
+}
+
+//RopeSeqs are not concurrent; return false
+func (s *RopeSeq) IsConcurrent() bool {return false}
+
+//returns the first item in a sequence for which f returns true or nil if none is found
+func (s *RopeSeq) Find(f func(el El)bool) El {
+	el, _ := s.root.find(f)
+	return el
+}
+
+//returns a new RopeSeq consisting of all of the elements of s except for the first one
+func (s *RopeSeq) Rest() Sequence {return s.Slice(1, s.Len())}
+
+//returns the length of s
+func (s *RopeSeq) Len() int {return s.root.len()}
+
+func (s *RopeSeq) Describe() Description {return Description{"RopeSeq", map[string]interface{}{"len": s.Len(), "depth": s.root.depth()}, nil}}
+
+//returns a new RopeSeq of the elements of s from start up to, but not including, end; the bounds are clamped to s
+func (s *RopeSeq) Slice(start, end int) Sequence {
+	rest, _ := ropeSplit(s.root, end)
+	_, slice := ropeSplit(rest, start)
+	return Sequence{&RopeSeq{slice}}
+}
+
+//returns the element of s at index, or nil if there is none
+func (s *RopeSeq) Nth(index int) El {
+	if index < 0 || index >= s.Len() {return nil}
+	n := s.root
+	for n.leaf == nil {
+		if index < n.left.length {
+			n = n.left
+		} else {
+			index -= n.left.length
+			n = n.right
+		}
+	}
+	return n.leaf[index]
+}
+
+//returns a new RopeSeq consisting of s followed by s2; s2 is copied unless it is also a RopeSeq
+func (s *RopeSeq) Append(s2 Sequence) Sequence {return Sequence{&RopeSeq{ropeJoin(s.root, ropeOf(s2))}}}
+
+//returns a new RopeSeq consisting of s2 followed by s; s2 is copied unless it is also a RopeSeq
+func (s *RopeSeq) Prepend(s2 Sequence) Sequence {return Sequence{&RopeSeq{ropeJoin(ropeOf(s2), s.root)}}}
@@ -0,0 +1,116 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "hash/fnv"
+import "sync"
+
+//the number of elements each output of split holds for its consumer
+const splitBuffer = 64
+
+//starts traversing s in the background and returns n ConcurrentSeqs, sending each element of s to the one choose picks, with bounded buffering
+func (s Sequence) split(stage string, n int, choose func(el El) int) []Sequence {
+	outs := make([]chan interface{}, n)
+	quits := make([]chan bool, n)
+	for i := range outs {
+		outs[i] = make(chan interface{}, splitBuffer)
+		quits[i] = make(chan bool)
+	}
+	go func() {
+		s.Do(func(el El){
+			i := choose(el)
+			select {
+			case outs[i] <- el:
+			case <- quits[i]:
+			}
+		})
+		for _, out := range outs {close(out)}
+	}()
+	result := make([]Sequence, n)
+	for i := range result {
+		out, quit := outs[i], quits[i]
+		var once sync.Once
+		result[i] = Gen(func(c SeqChan){
+			for el := <- out; !closed(out); el = <- out {
+				c <- el
+				if closed(c) {
+					once.Do(func(){close(quit)})
+					return
+				}
+			}
+		}).stage(stage, s, "output", i)
+	}
+	return result
+}
+
+//returns len(routes) + 1 ConcurrentSeqs fed by one traversal of s, which starts now: each element goes to the output of the first route that returns true for it, or to the last output if none does.  Each output buffers up to 64 elements; when one is full, the traversal waits for its consumer, so the outputs should be consumed concurrently.  Abandoning an output's traversal drops the rest of its elements instead.  The outputs share a single traversal of s, so each should be traversed only once
+func (s Sequence) Route(routes... func(el El) bool) []Sequence {
+	return s.split("Route", len(routes) + 1, func(el El) int {
+		for i, route := range routes {
+			if route(el) {return i}
+		}
+		return len(routes)
+	})
+}
+
+//returns n ConcurrentSeqs fed by one traversal of s, which starts now, sending each element to the output chosen by hashing key(el), so all elements with equal keys go to the same output and can be aggregated there independently of the other outputs.  Keys are hashed by their type and printed value, as HashSeq hashes elements.  Buffering and consumption work as for Route.  An n less than 1 is misuse, reported according to s's Policy, and returns no outputs
+func (s Sequence) Shard(n int, key func(el El) interface{}) []Sequence {
+	if n < 1 {
+		s.check(seqError("Shard", "shard count %d is less than 1", n))
+		return []Sequence{}
+	}
+	h := fnv.New64a()
+	return s.split("Shard", n, func(el El) int {
+		h.Reset()
+		defaultHashEl(key(el), h)
+		return int(h.Sum64() % uint64(n))
+	})
+}
+
+//an element for a CMapByKey worker, with the channel for its result
+type keyedJob struct {
+	el El
+	reply chan El
+}
+
+//returns a new ConcurrentSeq of the results of applying f to each element of s, in the order of s, like CMap, except that f is applied to the elements with equal keys one at a time and in order, so f can keep state for each key.  Keys are dealt out to parallelism goroutines by hash, as in Shard, so elements with different keys are processed concurrently unless their keys share a goroutine
+func (s Sequence) CMapByKey(key func(el El) interface{}, f func(el El) El, parallelism int) Sequence {
+	if parallelism < 1 {parallelism = 1}
+	return Gen(func(c SeqChan){
+		workers := make([]chan keyedJob, parallelism)
+		for i := range workers {
+			workers[i] = make(chan keyedJob, splitBuffer)
+			go func(jobs chan keyedJob) {
+				for job := range jobs {job.reply <- f(job.el)}
+			}(workers[i])
+		}
+		//the result channels in the order of s, so results can be emitted in order as they finish
+		pending := make(chan chan El, splitBuffer * parallelism)
+		stop := make(chan bool)
+		go func() {
+			h := fnv.New64a()
+			s.While(func(el El)bool{
+				reply := make(chan El, 1)
+				select {
+				case pending <- reply:
+				case <- stop: return false
+				}
+				h.Reset()
+				defaultHashEl(key(el), h)
+				workers[h.Sum64() % uint64(parallelism)] <- keyedJob{el, reply}
+				return true
+			})
+			close(pending)
+			for _, jobs := range workers {close(jobs)}
+		}()
+		for reply := range pending {
+			c <- <- reply
+			if closed(c) {
+				close(stop)
+				return
+			}
+		}
+	}).stage("CMapByKey", s, "parallelism", parallelism).inheritMetrics(s)
+}
@@ -0,0 +1,32 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "os"
+
+//a cursor over the rows of a database query; database drivers' result sets can be adapted to it.  If a Rows also has an Err() os.Error method, FromRows checks it after the last row
+type Rows interface {
+	Next() bool
+	Close() os.Error
+}
+
+//returns a new ConcurrentSeq of the results of applying scan to each row of rows.  The rows are closed when they run out, when scan fails, or when the consumer stops reading; a scan error ends the sequence and is available from its Err method.  Since rows can only be read once, so can the sequence.  If rows is a LenHinter, so is the sequence
+func FromRows(rows Rows, scan func(rows Rows) (El, os.Error)) Sequence {
+	result := GenErr(func(c SeqChan) (err os.Error) {
+		defer func() {
+			if cerr := rows.Close(); err == nil {err = cerr}
+		}()
+		for rows.Next() {
+			el, err := scan(rows)
+			if err != nil {return err}
+			c <- el
+			if closed(c) {return nil}
+		}
+		if r, ok := rows.(interface{Err() os.Error}); ok {return r.Err()}
+		return nil
+	}).sourceStage("FromRows")
+	if h, ok := rows.(LenHinter); ok {return result.WithLenHint(h.LenHint())}
+	return result
+}
@@ -23,28 +23,65 @@ type Seq interface {
 	IsConcurrent() bool
 }
 
+//Seq implementations that decorate another Seq without changing its elements
+type seqWrapper interface {
+	unwrap() Seq
+}
+
+//strips any decorating wrappers from s
+func (s Sequence) base() Sequence {
+	for w, ok := s.Seq.(seqWrapper); ok; w, ok = s.Seq.(seqWrapper) {s = Sequence{w.unwrap()}}
+	return s
+}
+
+//returns s's Seq followed by the Seqs it decorates, outermost first
+func (s Sequence) layers() []Seq {
+	result := []Seq{s.Seq}
+	for w, ok := s.Seq.(seqWrapper); ok; w, ok = result[len(result) - 1].(seqWrapper) {
+		result = append(result, w.unwrap())
+	}
+	return result
+}
+
 //convert a sequence to a concurrent sequence (if necessary)
 func (s Sequence) Concurrent() Sequence {
 	if s.IsConcurrent() {return s}
 	return Gen(func(c SeqChan){s.Output(c)})
 }
 
+//starts s and returns the channel its elements arrive on, converting s to a ConcurrentSeq if necessary
+func (s Sequence) channel() SeqChan {
+	if seq, ok := s.base().Seq.(ConcurrentSeq); ok {return seq()}
+	return Gen(func(c SeqChan){s.Output(c)}).Seq.(ConcurrentSeq)()
+}
+
 //convert a sequence to a sequential sequence (if necessary)
 func (s Sequence) Sequential() Sequence {
-	switch seq := s.Seq.(type) {case *SequentialSeq: return s}
+	switch s.base().Seq.(type) {case *SequentialSeq: return s}
 	return s.SMap(func(el El)El{return el})
 }
 
-//returns a new array of the first N items
+//returns a new array of the first N items; if s is too short, its Policy decides whether to panic or to leave the missing items nil
 func (s Sequence) FirstN(n int) []interface{} {
+	r, err := s.FirstNErr(n)
+	s.check(err)
+	return r
+}
+
+//returns a new array of the first N items and a *SeqError if s has fewer than n items
+func (s Sequence) FirstNErr(n int) ([]interface{}, os.Error) {
+	if n < 0 {return nil, seqError("FirstN", "negative count %d", n)}
 	r := make([]interface{}, n)
 	x := 0
-	s.Find(func(el El)bool{
-		r[x] = el
-		x++
-		return x == n
-	})
-	return r
+	if n > 0 {
+		s.Find(func(el El)bool{
+			r[x] = el
+			x++
+			return x == n
+		})
+	}
+	if x < n {return r, seqError("FirstN", "wanted %d items but the sequence has only %d", n, x)}
+	return r, nil
 }
 
 //convenience function with multiple return values
@@ -83,24 +120,47 @@ func IsSeq(s interface{}) bool {
 	return test
 }
 
-//returns the first item in a sequence
+//returns the first item in a sequence, without consuming it if s comes from Peekable
 func (s Sequence) First() interface{} {
-	var result interface{}
-	s.Find(func(el El)bool{
-		result = el
-		return true
-	})
+	result, _ := s.Peek()
 	return result
 }
 
-//returns whether a sequence is empty
-func (s Sequence) IsEmpty() bool {
-	empty := true
+//returns the position of the first element of s for which f returns true, or -1 if there is none
+func (s Sequence) FindIndex(f func(el El)bool) int {
+	index, found := 0, false
 	s.Find(func(el El)bool{
-		empty = false
-		return true
+		found = f(el)
+		if !found {index++}
+		return found
 	})
-	return empty
+	if !found {return -1}
+	return index
+}
+
+//returns the last element of s for which f returns true and whether there is one; a SequentialSeq is searched from the end, anything else is read to the end
+func (s Sequence) FindLast(f func(el El)bool) (El, bool) {
+	if slice, ok := s.base().Seq.(*SequentialSeq); ok {
+		for i := len(*slice) - 1; i >= 0; i-- {
+			if f((*slice)[i]) {return (*slice)[i], true}
+		}
+		return nil, false
+	}
+	var result El
+	found := false
+	s.Do(func(el El){
+		if f(el) {
+			result = el
+			found = true
+		}
+	})
+	return result, found
+}
+
+//returns whether a sequence is empty, without consuming its first item if s comes from Peekable
+func (s Sequence) IsEmpty() bool {
+	_, found := s.Peek()
+	return !found
 }
 
 //applies f to each item in the sequence until f returns false
@@ -115,34 +175,52 @@ func (s Sequence) Do(f func(el El)) {
 }
 
 //applies f concurrently to each element of s, in no particular order; sizePowerOpt will default to {6} and CMap will allow up to 1 << sizePowerOpt[0] outstanding concurrent instances of f at any time
-func (s Sequence) CDo(f func(el El), sizePowerOpt... uint) {
-	c := s.CMap(func(el El)El{f(el); return nil}, sizePowerOpt...).Seq.(ConcurrentSeq)()
+func (s Sequence) CDo(f func(el El), sizePowerOpt... uint) {s.cdo("CDo", f, sizePowerOpt...)}
+
+//CDo, reporting to s's Metrics as stage
+func (s Sequence) cdo(stage string, f func(el El), sizePowerOpt... uint) {
+	c := s.cmap(stage, false, func(el El)El{f(el); return nil}, sizePowerOpt...).channel()
 	for <- c; !closed(c); <- c {}
 }
 
-//sends each item of s to c
-func (s Sequence) Output(c SeqChan) {s.Do(func(el El){c <- el})}
+//sends each item of s to c, stopping early if c's consumer closes it
+func (s Sequence) Output(c SeqChan) {
+	if p, ok := s.base().Seq.(*peekSeq); ok {
+		p.output(c)
+		return
+	}
+	s.While(func(el El)bool{
+		c <- el
+		return !closed(c)
+	})
+}
 
-//returns a new sequence of the same type as s1 that appends this s1 and s2
+//returns a new sequence that appends s1 and s2: a ConcurrentSeq if s1 is concurrent, a RopeSeq in O(log n) if s1 is one, and otherwise, when s2 is not concurrent either, a view that refers to s1 and s2 instead of copying them, so building a sequence with repeated Appends takes linear time.  Sequential or ToSlice copies a view's elements into a SequentialSeq when one is needed.  A concurrent s2 is read now, into a SequentialSeq
 func (s1 Sequence) Append(s2 Sequence) Sequence {
 	if s1.IsConcurrent() {return s1.CAppend(s2)}
+	if rope, ok := s1.base().Seq.(*RopeSeq); ok {return rope.Append(s2)}
+	if !s2.IsConcurrent() {return concat(s1, s2)}
 	return s1.SAppend(s2)
 }
 
-//returns a new sequence of the same type as s1 that appends this s1 and s2
+//returns a new sequence that prepends s2 to s1: a ConcurrentSeq if s1 is concurrent, a ConsSeq sharing s1 if s1 is one, a RopeSeq in O(log n) if s1 is one, and otherwise, when s2 is not concurrent either, a view that refers to s2 and s1 instead of copying them, as for Append.  A concurrent s2 is read now, into a SequentialSeq
 func (s1 Sequence) Prepend(s2 Sequence) Sequence {
 	if s1.IsConcurrent() {return s2.CAppend(s1)}
+	if list, ok := s1.base().Seq.(*ConsSeq); ok {return list.prependAll(s2)}
+	if rope, ok := s1.base().Seq.(*RopeSeq); ok {return rope.Prepend(s2)}
+	if !s2.IsConcurrent() {return concat(s2, s1)}
 	return s2.SAppend(s1)
 }
 
 func (s Sequence) ToSlice() []interface{} {
-	return *(*[]interface{})(s.Sequential().Seq.(*SequentialSeq))
+	return *(*[]interface{})(s.Sequential().base().Seq.(*SequentialSeq))
 }
 
 //returns a new SequentialSeq which consists of appending s and s2
 func (s Sequence) SAppend(s2 Sequence) Sequence {
-	slice := s.ToSlice()
-	slice = append(slice, s2.ToSlice()...)
+	slice := make([]interface{}, 0, s.quickLen(8) + s2.quickLen(8))
+	s.Do(func(el El){slice = append(slice, el)})
+	s2.Do(func(el El){slice = append(slice, el)})
 	return Sequence{(*SequentialSeq)(&slice)}
 }
 
@@ -150,16 +228,38 @@ func (s Sequence) SAppend(s2 Sequence) Sequence {
 func (s Sequence) CAppend(s2 Sequence) Sequence {
 	return Gen(func(c SeqChan){
 		s.Output(c)
-		s2.Output(c)
+		if !closed(c) {s2.Output(c)}
 	})
 }
 
-//if s is a SequentialSeq, return its length, otherwise return d
+//returns a new sequence of the same type as s consisting of the elements gen emits: a ConcurrentSeq running gen in the background, described as stage, or a SequentialSeq built by running gen now
+func (s Sequence) produce(stage string, gen func(emit func(el El))) Sequence {
+	if s.IsConcurrent() {
+		return Gen(func(c SeqChan){gen(func(el El){c <- el})}).stage(stage, s)
+	}
+	slice := make([]interface{}, 0, s.quickLen(8))
+	gen(func(el El){slice = append(slice, el)})
+	return Sequence{(*SequentialSeq)(&slice)}.inheritPolicy(s)
+}
+
+//return s's length hint if it has one, or its length if it is stored rather than computed, otherwise return d rather than traversing s.  Other sequential Seqs, like LazyFilter's views, would have to run their functions to count, repeating any side effects
 func (s Sequence) quickLen(d int) int {
-	switch seq := s.Seq.(type) {case *SequentialSeq: return s.Len()}
+	if n, _, ok := s.lenHint(); ok {return n}
+	switch s.base().Seq.(type) {
+	case *SequentialSeq, *IntSeq, *Float64Seq, *ConsSeq, *RopeSeq, *SpillSeq: return s.Len()
+	}
 	return d
 }
 
+//the most that operators whose results may be much shorter than their input preallocate up front
+const maxPrealloc = 1 << 16
+
+//returns quickLen(d), limited to maxPrealloc so a selective filter of a huge lazy sequence does not allocate for all of it
+func (s Sequence) guessLen(d int) int {
+	if n := s.quickLen(d); n < maxPrealloc {return n}
+	return maxPrealloc
+}
+
 //returns a new sequence of the same type as s consisting of the elements of s for which filter returns true
 func (s Sequence) Filter(filter func(e El)bool) Sequence {
 	if s.IsConcurrent() {return s.CFilter(filter)}
@@ -171,16 +271,20 @@ func ifFunc(condition func(e El)bool, op func(e El)) func(el El){return func(el
 //returns a new SequentialSeq consisting of the elements of s for which filter returns true
 func (s Sequence) SFilter(filter func(e El)bool) Sequence {
 	//continue shrinking
-	slice := make([]interface{}, 0, s.quickLen(8))
+	slice := make([]interface{}, 0, s.guessLen(8))
 	s.Do(ifFunc(filter, func(el El){slice = append(slice, el)}))
-	return Sequence{(*SequentialSeq)(&slice)}
+	return Sequence{(*SequentialSeq)(&slice)}.inheritPolicy(s)
 }
 
 //returns a new ConcurrentSeq consisting of the elements of s for which filter returns true; sizePowerOpt will default to {6} and CMap will allow up to 1 << sizePowerOpt[0] outstanding concurrent instances of f at any time
 func (s Sequence) CFilter(filter func(e El)bool, sizePowerOpt... uint) Sequence {
+	m := s.Metrics()
 	return Gen(func(c SeqChan){
-		s.CDo(ifFunc(filter, func(el El){c <- el}), sizePowerOpt...)
-	})
+		s.cdo("CFilter", ifFunc(filter, func(el El){
+			c <- el
+			m.Out("CFilter")
+		}), sizePowerOpt...)
+	}).stage("CFilter", s, "sizePower", sizePowerOf(sizePowerOpt)).inheritMetrics(s)
 }
 
 //returns a new sequence of the same type as s consisting of the results of appying f to the elements of s
@@ -193,114 +297,151 @@ func (s Sequence) Map(f func(el El) El) Sequence {
 func (s Sequence) SMap(f func(i El)El) Sequence {
 	slice := make([]interface{}, 0, s.quickLen(8))
 	s.Do(func(el El){slice = append(slice, f(el))})
-	return Sequence{(*SequentialSeq)(&slice)}
+	return Sequence{(*SequentialSeq)(&slice)}.inheritPolicy(s)
 }
 
 type reply struct {
 	index int;
 	result El
-}
-
-type swEntry struct {
-	value El
-	present bool
-}
-
-// like a slice of a sparse vector where capacity is always a power of 2
-// it uses a ring buffer so RemoveFirst is efficient
-type SlidingWindow struct {
-	start, base, count, mask int
-	values []swEntry
-}
-//creates a new SlidingWindow with capacity size
-func NewSlidingWindow(sz uint) *SlidingWindow {return &SlidingWindow{0, 0, 0, (1 << sz) - 1, make([]swEntry, 1 << sz)}}
-//returns the current maximum available index
-func (r *SlidingWindow) Max() int {return r.base + len(r.values) - 1}
-//returns the size of the window
-func (r *SlidingWindow) Capacity() int {return len(r.values)}
-//returns the number of items in the window
-func (r *SlidingWindow) Count() int {return r.count}
-func (r *SlidingWindow) normalize(index int) int {return (index + len(r.values)) & r.mask}
-//returns whether the window is empty
-func (r *SlidingWindow) IsEmpty() bool {return r.count == 0}
-//returns whether the window has any available space
-func (r *SlidingWindow) IsFull() bool {return r.count == len(r.values)}
-//returns the first item, or nil if there is none, and also returns whether there was an item
-func (r *SlidingWindow) GetFirst() (interface{}, bool) {return r.values[r.start].value, r.values[r.start].present}
-//removes the first item, if there is one, and also returns whether an item was removed
-func (r *SlidingWindow) RemoveFirst() (interface{}, bool) {
-	result := r.values[r.start]
-	if !result.present {return nil, false}
-	r.values[r.start] = swEntry{nil, false}
-	r.count--
-	r.start = r.normalize(r.start + 1)
-	r.base++
-	return result.value, true
-}
-//returns item at index, if there is one, and also returns whether an item was there
-func (r *SlidingWindow) Get(index int) (interface{}, bool) {
-	index -= r.base
-	if index < 0 || index >= r.Capacity() {return nil, false}
-	index = r.normalize(index + r.start)
-	value := r.values[index]
-	return value.value, value.present
-}
-//sets the item at index to value, if the space is available, and also returns whether an item was set
-func (r *SlidingWindow) Set(index int, value interface{}) bool {
-	index -= r.base
-	if index < 0 || index >= r.Capacity() {return false}
-	index = r.normalize(index + r.start)
-	r.values[index].value = value
-	if !r.values[index].present {
-		r.values[index].present = true
-		r.count++
-	}
-	return true
-}
-
-//returns a new ConcurrentSeq consisting of the results of appying f to the elements of s; sizePowerOpt will default to {6} and CMap will allow up to 1 << sizePowerOpt[0] outstanding concurrent instances of f at any time
-func (s Sequence) CMap(f func(el El) El, sizePowerOpt... uint) Sequence {
-// spawn a goroutine that does the following for each value, with up to size pending at a time:
-//   spawn a goroutine to apply f to the value and send the result back in a channel
-// send the results in order to the ouput channel as they are completed
-	sizePower := uint(6)
-	if len(sizePowerOpt) > 0 {sizePower = sizePowerOpt[0]}
-	size := 1 << sizePower
+	//when the job was handed to a worker, in a job, or how long it took, in a reply; only measured for limiters that want it
+	ns int64
+}
+
+//decides how many elements CMap processes at once
+type limiter interface {
+	//the current limit
+	limit() int
+	//the most limit can ever return
+	max() int
+	//whether completed wants latencies
+	timed() bool
+	//called when an element completes, with the nanoseconds it took and the number of completed results waiting to be sent
+	completed(ns int64, backlog int)
+}
+
+//a limiter that always allows the same number
+type fixedLimit int
+
+func (l fixedLimit) limit() int {return int(l)}
+func (l fixedLimit) max() int {return int(l)}
+func (l fixedLimit) timed() bool {return false}
+func (l fixedLimit) completed(ns int64, backlog int) {}
+
+//returns a new ConcurrentSeq consisting of the results of appying f to the elements of s; sizePowerOpt will default to {6} and CMap will allow up to 1 << sizePowerOpt[0] outstanding concurrent instances of f at any time.  Completed results wait in a reorder window until the results before them are done; the window starts at 1 << sizePowerOpt[0] and can grow to 1 << sizePowerOpt[1], which defaults to 4 more than sizePowerOpt[0], so one slow element does not stall the rest
+func (s Sequence) CMap(f func(el El) El, sizePowerOpt... uint) Sequence {return s.cmap("CMap", true, f, sizePowerOpt...)}
+
+//CMap, reporting to s's Metrics as stage, including its output only if countOut is true
+func (s Sequence) cmap(stage string, countOut bool, f func(el El) El, sizePowerOpt... uint) Sequence {
+	sizePower := sizePowerOf(sizePowerOpt)
+	maxWindowPower := sizePower + 4
+	if len(sizePowerOpt) > 1 && sizePowerOpt[1] > sizePower {maxWindowPower = sizePowerOpt[1]}
+	return s.limitedMap(stage, countOut, f, fixedLimit(1 << sizePower), sizePower, maxWindowPower).stage(stage, s, "sizePower", sizePower, "maxWindowPower", maxWindowPower).inheritMetrics(s)
+}
+
+//the body of CMap: applies f to the elements of s with as many at once as lim allows, in a reorder window that starts at 1 << sizePower and can grow to 1 << maxWindowPower
+func (s Sequence) limitedMap(stage string, countOut bool, f func(el El) El, lim limiter, sizePower, maxWindowPower uint) Sequence {
+// spawn a goroutine that does the following for each value, with up to lim.limit() pending at a time:
+//   queue the value for a worker goroutine, which applies f to it and sends the result back in a channel;
+//   workers are started as needed, up to lim.max() of them, and reused for later values;
+//   if s has an Executor, it runs a job that takes one queued value instead
+// send the results in order to the ouput channel as they are completed, using a spare reorder window if there is one
+	m := s.Metrics()
+	f = s.timed(stage, m, f)
+	clock := s.Clock()
 	return Gen(func(output SeqChan){
 		//punt and convert sequence to concurrent
 		//maybe someday we'll handle SequentialSequences separately
-		input := s.Concurrent().Seq.(ConcurrentSeq)()
-		window := NewSlidingWindow(sizePower)
+		input := s.channel()
+		window := spareWindow(sizePower, maxWindowPower)
+		exec := s.executor()
 		replyChannel := make(chan reply)
-		inputCount, pendingInput := 0, 0
+		//an executor may run jobs on this goroutine, so their replies must not block
+		if exec != nil {replyChannel = make(chan reply, lim.max())}
+		//never fills: it holds at most the pending values no worker has taken yet
+		work := make(chan reply, lim.max())
+		run := func(job reply) {
+			result := f(job.result)
+			if job.ns != 0 {job.ns = clock.Now() - job.ns}
+			replyChannel <- reply{job.index, result, job.ns}
+		}
+		pull := func() {run(<- work)}
+		worker := func() {
+			for job := range work {run(job)}
+		}
+		inputCount, pendingInput, workers := 0, 0, 0
 		inputClosed := false
 		defer close(replyChannel)
+		defer close(work)
+		defer releaseWindow(window)
 		for !inputClosed || pendingInput > 0 || window.Count() > 0 {
 			first, hasFirst := window.GetFirst()
 			ic, oc, rc := input, output, replyChannel
 			if !hasFirst {oc = nil}
-			if inputClosed || pendingInput >= size {ic = nil}
-			if window.Count() >= size {rc = nil}
+			//only take input that is sure to fit in the window
+			if inputClosed || pendingInput >= lim.limit() || inputCount - window.Base() >= window.Limit() {ic = nil}
 			select {
-			case oc <- first: window.RemoveFirst()
+			case oc <- first:
+				window.RemoveFirst()
+				if countOut {m.Out(stage)}
 			case inputElement := <- ic:
 				if closed(ic) {
 					inputClosed = true
 				} else {
-					go func(index int, value interface{}) {
-						replyChannel <- reply{index, f(value)}
-					}(inputCount, inputElement)
+					job := reply{inputCount, inputElement, 0}
+					if lim.timed() {job.ns = clock.Now()}
 					inputCount++
 					pendingInput++
+					work <- job
+					if exec != nil {
+						exec.Execute(pull)
+					} else if workers < pendingInput {
+						workers++
+						go worker()
+					}
+					m.In(stage)
+					m.InFlight(stage, pendingInput)
 				}
 			case replyElement := <- rc:
 				window.Set(replyElement.index, replyElement.result)
 				pendingInput--
+				lim.completed(replyElement.ns, window.Count())
+				m.InFlight(stage, pendingInput)
 			}
 		}
 	})
 }
 
+//a free list of emptied reorder windows, so a long-lived pipeline that keeps starting CMaps, as CFlatMap does, reuses their buffers instead of allocating new ones
+var spareWindows = make(chan *SlidingWindow, 16)
+
+//returns an empty reorder window that starts at 1 << sizePower and can grow to 1 << maxWindowPower, reusing a spare one if it fits
+func spareWindow(sizePower, maxWindowPower uint) *SlidingWindow {
+	select {
+	case window := <- spareWindows:
+		if window.Capacity() <= 1 << maxWindowPower {
+			window.reset(1 << maxWindowPower)
+			return window
+		}
+	default:
+	}
+	return NewGrowableSlidingWindow(sizePower, maxWindowPower)
+}
+
+//puts window on the free list if it is empty and the list has room
+func releaseWindow(window *SlidingWindow) {
+	if !window.IsEmpty() {return}
+	select {
+	case spareWindows <- window:
+	default:
+	}
+}
+
+//returns the sizePower from an optional sizePower argument, defaulting to 6
+func sizePowerOf(sizePowerOpt []uint) uint {
+	if len(sizePowerOpt) > 0 {return sizePowerOpt[0]}
+	return 6
+}
+
 //returns a new sequence of the same type as s consisting of the concatenation of the sequences f returns when applied to all of the elements of s
 func (s Sequence) FlatMap(f func(el El) Sequence) Sequence {
 	if s.IsConcurrent() {return s.CFlatMap(f)}
@@ -309,18 +450,64 @@ func (s Sequence) FlatMap(f func(el El) Sequence) Sequence {
 
 //returns a new SequentialSeq consisting of the concatenation of the sequences f returns when applied to all of the elements of s
 func (s Sequence) SFlatMap(f func(i El) Sequence) Sequence {
-	slice := make([]interface{}, 0, s.quickLen(8))
+	slice := make([]interface{}, 0, s.guessLen(8))
 	s.Do(func(e El){f(e).Do(func(sub El){slice = append(slice, sub)})})
-	return Sequence{(*SequentialSeq)(&slice)}
+	return Sequence{(*SequentialSeq)(&slice)}.inheritPolicy(s)
 }
 
-//returns a new ConcurrentSeq consisting of the concatenation of the sequences f returns when applied to all of the elements of s; sizePowerOpt will default to {6} and CMap will allow up to 1 << sizePowerOpt[0] outstanding concurrent instances of f at any time
+//returns a new ConcurrentSeq consisting of the concatenation of the sequences f returns when applied to all of the elements of s; sizePowerOpt will default to {6} and CMap will allow up to 1 << sizePowerOpt[0] outstanding concurrent instances of f at any time.  The order is strict: f runs concurrently, but each sequence it returns is drained completely, in the order of s, before the next one starts; CFlatMapUnordered drains them concurrently instead
 func (s Sequence) CFlatMap(f func(i El) Sequence, sizePowerOpt... uint) Sequence {
+	m := s.Metrics()
 	return Gen(func(c SeqChan){
-		s.CMap(func(e El)El{return f(e)}, sizePowerOpt...).Do(func(sub El){
-			sub.(Sequence).Output(c)
+		s.cmap("CFlatMap", false, func(e El)El{return f(e)}, sizePowerOpt...).While(func(sub El)bool{
+			sub.(Sequence).While(func(el El)bool{
+				c <- el
+				m.Out("CFlatMap")
+				return !closed(c)
+			})
+			return !closed(c)
 		})
-	})
+	}).stage("CFlatMap", s, "sizePower", sizePowerOf(sizePowerOpt)).inheritMetrics(s)
+}
+
+//returns a new ConcurrentSeq of the elements of the sequences f returns when applied to the elements of s, like CFlatMap, but interleaved in whatever order they arrive: up to 1 << sizePowerOpt[0] elements of s, which defaults to {6}, have f applied and their sequences drained at a time, so slow sequences do not hold up the others
+func (s Sequence) CFlatMapUnordered(f func(el El) Sequence, sizePowerOpt... uint) Sequence {
+	return s.flatMapMerge("CFlatMapUnordered", f, 1 << sizePowerOf(sizePowerOpt))
+}
+
+//returns a new ConcurrentSeq of the elements of the sequences f returns when applied to the elements of s, draining up to maxConcurrentInner of those sequences at once and merging their elements as they arrive, for inner sequences that are slow generators of their own.  It is CFlatMapUnordered with an exact limit rather than a power of 2; a limit less than 1 is treated as 1
+func (s Sequence) CFlatMapMerge(f func(el El) Sequence, maxConcurrentInner int) Sequence {
+	if maxConcurrentInner < 1 {maxConcurrentInner = 1}
+	return s.flatMapMerge("CFlatMapMerge", f, maxConcurrentInner)
+}
+
+//returns a new ConcurrentSeq of the elements of the sequences f returns when applied to the elements of s, with inner goroutines each applying f to an element and draining its sequence, so the elements are interleaved as they arrive
+func (s Sequence) flatMapMerge(stage string, f func(el El) Sequence, inner int) Sequence {
+	m := s.Metrics()
+	return Gen(func(c SeqChan){
+		jobs := make(chan interface{})
+		done := make(chan bool)
+		for i := 0; i < inner; i++ {
+			go func() {
+				for el := range jobs {
+					f(el).While(func(sub El)bool{
+						c <- sub
+						m.Out(stage)
+						return !closed(c)
+					})
+				}
+				done <- true
+			}()
+		}
+		s.While(func(el El)bool{
+			if closed(c) {return false}
+			m.In(stage)
+			jobs <- el
+			return true
+		})
+		close(jobs)
+		for i := 0; i < inner; i++ {<- done}
+	}).stage(stage, s, "inner", inner).inheritMetrics(s)
 }
 
 //returns the result of applying f to its previous value and each element of s in succession, starting with init as the initial "previous value" for f
@@ -329,42 +516,75 @@ func (s Sequence) Fold(init interface{}, f func(acc, el El)El) interface{} {
 	return init
 }
 
-//returns a new sequence of the same type as s consisting of all possible combinations of the elements of s of size number or smaller
-func (s Sequence) Combinations(number int) Sequence {
-	if number == 0 || s.IsEmpty() {return From(From())}
-	return s.Rest().Combinations(number).Prepend(s.Rest().Combinations(number - 1).Map(func(el El)El{
-		return el.(Sequence).Prepend(From(s.First()))
-	}))
+//like Fold, but stops as soon as f returns false, yielding the accumulator f returned along with false; a concurrent s is closed at that point instead of being read to the end
+func (s Sequence) FoldWhile(init interface{}, f func(acc, el El) (El, bool)) interface{} {
+	s.While(func(el El)bool{
+		more := false
+		init, more = f(init, el)
+		return more
+	})
+	return init
 }
 
-//returns the product of the elements of sequences, where each element is a sequence
+//returns the product of the elements of sequences, where each element is a sequence; if an element is not a sequence, sequences' Policy decides whether to panic or to return an empty sequence
 func (sequences Sequence) Product() Sequence {
-	return sequences.Fold(From(From()), func(result, each El)El{
+	result, err := sequences.ProductErr()
+	sequences.check(err)
+	return result
+}
+
+//returns the product of the elements of sequences and a *SeqError if any element is not a sequence
+func (sequences Sequence) ProductErr() (Sequence, os.Error) {
+	var err os.Error
+	index := 0
+	result := sequences.FoldWhile(From(From()), func(result, each El) (El, bool) {
+		component, cerr := productComponent("Product", index, each)
+		if cerr != nil {
+			err = cerr
+			return result, false
+		}
+		index++
 		return result.(Sequence).FlatMap(func(seq El)Sequence{
-			return each.(Sequence).Map(func(i El) El {
+			return component.Map(func(i El) El {
 				return seq.(Sequence).Append(From(i))
 			})
-		})
-	}).(Sequence)
+		}), true
+	})
+	if err != nil {return From(), err}
+	return result.(Sequence), nil
 }
 
-//pretty print an object, followed by a newline.  Optional arguments are a map of names (map[interface{}]string) and an io.Writer to write output to
+//returns el, element index of the input to a product, as a Sequence, or a *SeqError for op if it is not one
+func productComponent(op string, index int, el El) (Sequence, os.Error) {
+	if component, ok := el.(Sequence); ok {return component, nil}
+	return Sequence{}, seqError(op, "element %d is a %T, not a Sequence", index, el)
+}
+
+//pretty print an object, followed by a newline.  Optional arguments are a map of names (map[interface{}]string), an io.Writer to write output to, a PrettyMode, and *PrettyColors
 func Prettyln(s interface{}, rest... interface{}) {
 	writer := Pretty(s, rest...)
 	fmt.Fprintln(writer)
 }
-//pretty print an object.  Optional arguments are a map of names (map[interface{}]string) and an io.Writer to write output to
+//pretty print an object.  Optional arguments are a map of names (map[interface{}]string), an io.Writer to write output to, a PrettyMode, and *PrettyColors
 func Pretty(s interface{}, args... interface{}) io.Writer {
 	var writer io.Writer = os.Stdout
 	var names map[interface{}]string
+	var colors *PrettyColors
+	mode := PrettyBrackets
 	for i := 0; i < len(args); i++ {
 		switch arg := args[i].(type) {
 		case map[interface{}]string: names = arg
 		case io.Writer: writer = arg
+		case PrettyMode: mode = arg
+		case *PrettyColors: colors = arg
 		}
 	}
 	if names == nil {names = map[interface{}]string{}}
-	prettyLevel(s, 0, names, writer)
+	switch mode {
+	case PrettyJSON: prettyStructured(s, 0, &jsonStyle, names, writer, map[interface{}]bool{})
+	case PrettySexp: prettyStructured(s, 0, &sexpStyle, names, writer, map[interface{}]bool{})
+	default: prettyLevel(s, 0, names, writer, map[interface{}]bool{}, colors)
+	}
 	return writer
 }
 
@@ -388,14 +608,23 @@ func hasName(names map[interface{}]string, v interface{}) bool {
 }
 
 //This pretty is ugly :)
-func prettyLevel(s interface{}, level int, names map[interface{}]string, w io.Writer) {
+//path holds the sequences being printed, so a sequence that contains itself prints as a back-reference instead of looping forever
+func prettyLevel(s interface{}, level int, names map[interface{}]string, w io.Writer, path map[interface{}]bool, colors *PrettyColors) {
 	name, has := getName(names, s)
 	if has {
-		fmt.Fprint(w, name)
+		fmt.Fprint(w, colors.name(name))
 	} else switch arg := s.(type) {
-	case Sequence: prettyLevel(arg.Seq, level, names, w)
+	case Sequence: prettyLevel(arg.Seq, level, names, w, path, colors)
 	case Seq:
-		fmt.Fprintf(w, "%*s%s", level, "", "[")
+		if hashable(arg) {
+			if path[arg] {
+				fmt.Fprintf(w, "%*s<cycle>", level, "")
+				return
+			}
+			path[arg] = true
+			defer func(){path[arg] = false, false}()
+		}
+		fmt.Fprintf(w, "%*s%s", level, "", colors.bracket("[", level / 4))
 		first := true
 		innerSeq := false
 		named := false
@@ -411,7 +640,7 @@ func prettyLevel(s interface{}, level int, names map[interface{}]string, w io.Wr
 				fmt.Fprint(w, ", ")
 			}
 			if innerSeq {
-				prettyLevel(v.(Sequence), level + 4, names, w)
+				prettyLevel(v.(Sequence), level + 4, names, w, path, colors)
 			} else {
 				fmt.Fprintf(w, "%v", v)
 			}
@@ -421,7 +650,7 @@ func prettyLevel(s interface{}, level int, names map[interface{}]string, w io.Wr
 				fmt.Fprintf(w, "\n%*s", level, "")
 			}
 		}
-		fmt.Fprintf(w, "]")
+		fmt.Fprint(w, colors.bracket("]", level / 4))
 	default:
 		fmt.Print(arg)
 	}
@@ -448,11 +677,11 @@ func Gen(f func(c SeqChan)) Sequence {
 
 //returns a new ConcurrentSeq consisting of the numbers from 0 to limit, in succession
 func CUpto(limit int) Sequence {
-	return Sequence(Gen(func(c SeqChan) {
+	return GenSized(limit, func(c SeqChan) {
 		for i := 0; i < limit; i++ {
 			c <- i
 		}
-	}))
+	})
 }
 
 //ConcurrentSeqs are concurrent; return true
@@ -500,9 +729,36 @@ func (s ConcurrentSeq) ToSequentialSeq() Sequence {
 // a sequential sequence
 type SequentialSeq []interface{}
 
-//returns a new SequentialSeq consisting of els
+//returns a new SequentialSeq consisting of els.  When els is passed as slice..., the sequence and its Rests use slice's array rather than a copy, so later changes to slice show through; FromCopy or Copy gives a sequence that owns its elements
 func From(els... interface{}) Sequence {return Sequence{(*SequentialSeq)(&els)}}
 
+//returns a new SequentialSeq consisting of a copy of els, so changing a slice passed as els... afterwards does not affect it
+func FromCopy(els... interface{}) Sequence {return From(append([]interface{}(nil), els...)...)}
+
+//returns a new SequentialSeq consisting of a copy of the elements of s, which owns its array, so it is safe to share even if the array s was made from is changed later
+func (s Sequence) Copy() Sequence {
+	slice := make([]interface{}, 0, s.quickLen(8))
+	s.Do(func(el El){slice = append(slice, el)})
+	return Sequence{(*SequentialSeq)(&slice)}
+}
+
+//returns a new sequence of the elements of slice, which can be a slice of any type; []interface{}, []int, and []float64 are used without copying, as a SequentialSeq, IntSeq, or Float64Seq, and other slices are copied into a SequentialSeq.  A slice that is not a slice is misuse, reported according to DefaultPolicy
+func FromSlice(slice interface{}) Sequence {
+	switch els := slice.(type) {
+	case []interface{}: return Sequence{(*SequentialSeq)(&els)}
+	case []int: return FromInts(els)
+	case []float64: return FromFloat64s(els)
+	}
+	v, ok := reflect.NewValue(slice).(*reflect.SliceValue)
+	if !ok {
+		From().check(seqError("FromSlice", "%T is not a slice", slice))
+		return From()
+	}
+	els := make([]interface{}, v.Len())
+	for i := range els {els[i] = v.Elem(i).Interface()}
+	return Sequence{(*SequentialSeq)(&els)}
+}
+
 //returns a new SequentialSeq consisting of the numbers from 0 to limit, in succession
 func SUpto(limit int) Sequence {
 	a := make([]interface{}, limit)
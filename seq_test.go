@@ -0,0 +1,95 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "testing"
+
+//the number of elements the sequential benchmarks run over
+const benchmarkSize = 1000000
+
+//returns a new SequentialSeq of the ints from 0 up to size, built with the benchmark timer stopped
+func benchmarkInput(b *testing.B, size int) Sequence {
+	b.StopTimer()
+	defer b.StartTimer()
+	items := make([]interface{}, size)
+	for i := range items {items[i] = i}
+	return Sequence{(*SequentialSeq)(&items)}
+}
+
+func increment(el El) El {return el.(int) + 1}
+
+func BenchmarkSMap(b *testing.B) {
+	s := benchmarkInput(b, benchmarkSize)
+	for i := 0; i < b.N; i++ {
+		s.SMap(increment)
+	}
+}
+
+func BenchmarkSFilter(b *testing.B) {
+	s := benchmarkInput(b, benchmarkSize)
+	for i := 0; i < b.N; i++ {
+		s.SFilter(func(el El)bool{return el.(int) & 1 == 0})
+	}
+}
+
+//the number of elements the concurrent benchmarks run over
+const concurrentBenchmarkSize = 100000
+
+//a reference for BenchmarkCMap: CMap's original approach, which started a goroutine for every element instead of reusing workers
+func goroutinePerElementMap(s Sequence, f func(el El) El, sizePower uint) Sequence {
+	return Gen(func(output SeqChan){
+		input := s.channel()
+		window := NewSlidingWindow(sizePower)
+		replyChannel := make(chan reply)
+		inputCount, pendingInput := 0, 0
+		inputClosed := false
+		defer close(replyChannel)
+		for !inputClosed || pendingInput > 0 || window.Count() > 0 {
+			first, hasFirst := window.GetFirst()
+			ic, oc := input, output
+			if !hasFirst {oc = nil}
+			if inputClosed || inputCount - window.Base() >= window.Capacity() {ic = nil}
+			select {
+			case oc <- first:
+				window.RemoveFirst()
+			case inputElement := <- ic:
+				if closed(ic) {
+					inputClosed = true
+				} else {
+					go func(index int, value interface{}) {
+						replyChannel <- reply{index, f(value), 0}
+					}(inputCount, inputElement)
+					inputCount++
+					pendingInput++
+				}
+			case rep := <- replyChannel:
+				window.Set(rep.index, rep.result)
+				pendingInput--
+			}
+		}
+	})
+}
+
+func BenchmarkCMap(b *testing.B) {
+	s := benchmarkInput(b, concurrentBenchmarkSize)
+	for i := 0; i < b.N; i++ {
+		s.CMap(increment).Do(func(el El){})
+	}
+}
+
+func BenchmarkCMapGoroutinePerElement(b *testing.B) {
+	s := benchmarkInput(b, concurrentBenchmarkSize)
+	for i := 0; i < b.N; i++ {
+		goroutinePerElementMap(s, increment, 6).Do(func(el El){})
+	}
+}
+
+//many short CMaps, as CFlatMap starts, which reuse their reorder windows
+func BenchmarkCMapShortStreams(b *testing.B) {
+	s := benchmarkInput(b, 16)
+	for i := 0; i < b.N; i++ {
+		s.CMap(increment).Do(func(el El){})
+	}
+}
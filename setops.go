@@ -0,0 +1,122 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+//returns the key function from an optional argument, defaulting to the element itself
+func keyOf(keyOpt []func(el El) interface{}) func(el El) interface{} {
+	if len(keyOpt) > 0 && keyOpt[0] != nil {return keyOpt[0]}
+	return func(el El) interface{} {return el}
+}
+
+//returns a new sequence of the same type as s consisting of the distinct elements of s followed by the distinct elements of other that are not in s.  Elements are the same if their keys are equal; keyOpt defaults to the element itself
+func (s Sequence) Union(other Sequence, keyOpt... func(el El) interface{}) Sequence {
+	key := keyOf(keyOpt)
+	return s.produce("Union", func(emit func(el El)){
+		seen := map[interface{}]bool{}
+		add := func(el El){
+			if k := key(el); !seen[k] {
+				seen[k] = true
+				emit(el)
+			}
+		}
+		s.Do(add)
+		other.Do(add)
+	})
+}
+
+//returns a new sequence of the same type as s consisting of the distinct elements of s whose keys are also in other, in the order other first has them.  s is indexed and other is streamed; keyOpt defaults to the element itself
+func (s Sequence) Intersect(other Sequence, keyOpt... func(el El) interface{}) Sequence {
+	key := keyOf(keyOpt)
+	return s.produce("Intersect", func(emit func(el El)){
+		index := map[interface{}]El{}
+		s.Do(func(el El){
+			if k := key(el); !hasKey(index, k) {index[k] = el}
+		})
+		other.Do(func(el El){
+			k := key(el)
+			if first, ok := index[k]; ok {
+				emit(first)
+				index[k] = nil, false
+			}
+		})
+	})
+}
+
+//returns a new sequence of the same type as s consisting of the distinct elements of s whose keys are not in other, in order.  s is indexed and other is streamed; keyOpt defaults to the element itself
+func (s Sequence) Difference(other Sequence, keyOpt... func(el El) interface{}) Sequence {
+	key := keyOf(keyOpt)
+	return s.produce("Difference", func(emit func(el El)){
+		removed := map[interface{}]bool{}
+		items := s.ToSlice()
+		for _, el := range items {removed[key(el)] = false}
+		other.Do(func(el El){
+			if k := key(el); hasBool(removed, k) {removed[k] = true}
+		})
+		for _, el := range items {
+			if k := key(el); !removed[k] {
+				removed[k] = true
+				emit(el)
+			}
+		}
+	})
+}
+
+//returns a new sequence of the same type as s consisting of all of the elements of s followed by all of the elements of other, as a multiset union
+func (s Sequence) UnionAll(other Sequence) Sequence {
+	return s.produce("UnionAll", func(emit func(el El)){
+		s.Do(emit)
+		other.Do(emit)
+	})
+}
+
+//returns a new sequence of the same type as s with the elements of s whose keys are also in other, as a multiset: a key occurring m times in s and n times in other occurs min(m, n) times, using the elements of s in order.  The result follows the order of other; keyOpt defaults to the element itself
+func (s Sequence) IntersectAll(other Sequence, keyOpt... func(el El) interface{}) Sequence {
+	key := keyOf(keyOpt)
+	return s.produce("IntersectAll", func(emit func(el El)){
+		index := map[interface{}][]interface{}{}
+		s.Do(func(el El){
+			k := key(el)
+			index[k] = append(index[k], el)
+		})
+		other.Do(func(el El){
+			k := key(el)
+			if matches := index[k]; len(matches) > 0 {
+				emit(matches[0])
+				index[k] = matches[1:]
+			}
+		})
+	})
+}
+
+//returns a new sequence of the same type as s with the elements of s less those in other, as a multiset: a key occurring m times in s and n times in other occurs max(m - n, 0) times, dropping its first occurrences in s.  keyOpt defaults to the element itself
+func (s Sequence) DifferenceAll(other Sequence, keyOpt... func(el El) interface{}) Sequence {
+	key := keyOf(keyOpt)
+	return s.produce("DifferenceAll", func(emit func(el El)){
+		counts := map[interface{}]int{}
+		items := s.ToSlice()
+		for _, el := range items {counts[key(el)]++}
+		drop := map[interface{}]int{}
+		other.Do(func(el El){
+			if k := key(el); drop[k] < counts[k] {drop[k]++}
+		})
+		for _, el := range items {
+			if k := key(el); drop[k] > 0 {
+				drop[k]--
+			} else {
+				emit(el)
+			}
+		}
+	})
+}
+
+func hasKey(m map[interface{}]El, k interface{}) bool {
+	_, ok := m[k]
+	return ok
+}
+
+func hasBool(m map[interface{}]bool, k interface{}) bool {
+	_, ok := m[k]
+	return ok
+}
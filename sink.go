@@ -0,0 +1,76 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "fmt"
+import "io"
+import "os"
+
+//writes each element of s to w on its own line, formatted with %v, stopping at the first write error.  Wrap w with Paced to throttle the output
+func (s Sequence) WriteLines(w io.Writer) os.Error {return s.Fprint(w, "%v\n")}
+
+//writes each element of s to w, formatted with format, stopping at the first write error
+func (s Sequence) Fprint(w io.Writer, format string) os.Error {
+	var err os.Error
+	s.Find(func(el El)bool{
+		_, err = fmt.Fprintf(w, format, el)
+		return err != nil
+	})
+	if err == nil {err = s.Err()}
+	return err
+}
+
+//limits for a PacedWriter: at most BytesPerSecond bytes per second on average, if positive, and a flush at least every FlushInterval nanoseconds while writing, if positive.  A nil Clock means DefaultClock
+type Pacing struct {
+	BytesPerSecond int64
+	FlushInterval int64
+	Clock Clock
+}
+
+//an io.Writer which paces the writes to another writer, for throttling sinks like WriteLines at the sink.  Flushing calls the underlying writer's Flush method, if it has one
+type PacedWriter struct {
+	w io.Writer
+	pacing Pacing
+	started bool
+	start, lastFlush, written int64
+}
+
+//returns a new PacedWriter which writes to w according to pacing
+func Paced(w io.Writer, pacing Pacing) *PacedWriter {
+	if pacing.Clock == nil {pacing.Clock = DefaultClock}
+	return &PacedWriter{w: w, pacing: pacing}
+}
+
+//writes b to the underlying writer, in pieces of about a tenth of a second's budget, waiting as needed to keep within the byte rate
+func (p *PacedWriter) Write(b []byte) (int, os.Error) {
+	clock := p.pacing.Clock
+	if !p.started {
+		p.start, p.lastFlush, p.started = clock.Now(), clock.Now(), true
+	}
+	total := 0
+	for len(b) > 0 {
+		piece := b
+		if rate := p.pacing.BytesPerSecond; rate > 0 && int64(len(piece)) > rate / 10 + 1 {piece = b[:rate / 10 + 1]}
+		n, err := p.w.Write(piece)
+		total += n
+		p.written += int64(n)
+		if err != nil {return total, err}
+		b = b[n:]
+		if rate := p.pacing.BytesPerSecond; rate > 0 {
+			if wait := p.written * 1e9 / rate - (clock.Now() - p.start); wait > 0 {<- clock.After(wait)}
+		}
+		if p.pacing.FlushInterval > 0 && clock.Now() - p.lastFlush >= p.pacing.FlushInterval {
+			if err := p.Flush(); err != nil {return total, err}
+		}
+	}
+	return total, nil
+}
+
+//flushes the underlying writer, if it has a Flush method
+func (p *PacedWriter) Flush() os.Error {
+	p.lastFlush = p.pacing.Clock.Now()
+	if f, ok := p.w.(interface{Flush() os.Error}); ok {return f.Flush()}
+	return nil
+}
@@ -0,0 +1,245 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "sync"
+
+type swEntry struct {
+	value El
+	present bool
+}
+
+//A SlidingWindow is like a slice of a sparse vector where capacity is always a power of 2.
+//It holds the indexes from its base (the index of its first item) up to Max, any of which may be empty,
+//and it uses a ring buffer so RemoveFirst, which slides the window forward, is efficient.
+//CMap uses one as its reorder buffer: results are Set at their input index as they complete and removed from the front in order.
+//A growable window doubles its capacity when Set reaches past Max, up to its limit
+type SlidingWindow struct {
+	start, base, count, mask int
+	values []swEntry
+	limit int
+}
+//creates a new SlidingWindow with capacity 1 << sz, starting at index 0
+func NewSlidingWindow(sz uint) *SlidingWindow {return &SlidingWindow{0, 0, 0, (1 << sz) - 1, make([]swEntry, 1 << sz), 1 << sz}}
+//creates a new growable SlidingWindow with capacity 1 << sz, which Set can grow to 1 << maxSz
+func NewGrowableSlidingWindow(sz, maxSz uint) *SlidingWindow {
+	r := NewSlidingWindow(sz)
+	if maxSz > sz {r.limit = 1 << maxSz}
+	return r
+}
+//returns the capacity Set can grow the window to
+func (r *SlidingWindow) Limit() int {return r.limit}
+//returns the current maximum available index; Set fails beyond it
+func (r *SlidingWindow) Max() int {return r.base + len(r.values) - 1}
+//returns the size of the window
+func (r *SlidingWindow) Capacity() int {return len(r.values)}
+//returns the number of items in the window
+func (r *SlidingWindow) Count() int {return r.count}
+func (r *SlidingWindow) normalize(index int) int {return (index + len(r.values)) & r.mask}
+//returns whether the window is empty
+func (r *SlidingWindow) IsEmpty() bool {return r.count == 0}
+//returns whether the window has any available space
+func (r *SlidingWindow) IsFull() bool {return r.count == len(r.values)}
+//returns the first item, or nil if there is none, and also returns whether there was an item
+func (r *SlidingWindow) GetFirst() (interface{}, bool) {return r.values[r.start].value, r.values[r.start].present}
+//removes the first item, if there is one, and also returns whether an item was removed
+func (r *SlidingWindow) RemoveFirst() (interface{}, bool) {
+	result := r.values[r.start]
+	if !result.present {return nil, false}
+	r.values[r.start] = swEntry{nil, false}
+	r.count--
+	r.start = r.normalize(r.start + 1)
+	r.base++
+	return result.value, true
+}
+//returns item at index, if there is one, and also returns whether an item was there
+func (r *SlidingWindow) Get(index int) (interface{}, bool) {
+	index -= r.base
+	if index < 0 || index >= r.Capacity() {return nil, false}
+	index = r.normalize(index + r.start)
+	value := r.values[index]
+	return value.value, value.present
+}
+//sets the item at index to value, if the space is available or the window can grow to make it available, and also returns whether an item was set
+func (r *SlidingWindow) Set(index int, value interface{}) bool {
+	index -= r.base
+	if index < 0 || index >= r.limit {return false}
+	for index >= r.Capacity() {r.grow()}
+	index = r.normalize(index + r.start)
+	r.values[index].value = value
+	if !r.values[index].present {
+		r.values[index].present = true
+		r.count++
+	}
+	return true
+}
+//sets the item at index to value, doubling the capacity as many times as necessary to reach it, and returns whether an item was set; indexes before the window's base cannot be set
+func (r *SlidingWindow) SetOrGrow(index int, value interface{}) bool {
+	if index < r.base {return false}
+	for index > r.Max() {r.grow()}
+	if r.limit < len(r.values) {r.limit = len(r.values)}
+	return r.Set(index, value)
+}
+//doubles the capacity, keeping the items at the same indexes
+func (r *SlidingWindow) grow() {
+	values := make([]swEntry, len(r.values) * 2)
+	for i := 0; i < len(r.values); i++ {values[i] = r.values[r.normalize(r.start + i)]}
+	r.values, r.start, r.mask = values, 0, len(values) - 1
+}
+//empties the window, starting it again at index 0 with Set able to grow it to limit
+func (r *SlidingWindow) reset(limit int) {
+	for i := range r.values {r.values[i] = swEntry{nil, false}}
+	r.start, r.base, r.count, r.limit = 0, 0, 0, limit
+}
+//returns the index of the first slot in the window
+func (r *SlidingWindow) Base() int {return r.base}
+//calls f with the index and value of each item in the window, in index order
+func (r *SlidingWindow) Each(f func(index int, value interface{})) {
+	for i := 0; i < len(r.values); i++ {
+		if entry := r.values[r.normalize(r.start + i)]; entry.present {f(r.base + i, entry.value)}
+	}
+}
+//calls f with the index and value of each item in the window, in index order; the same as Each
+func (r *SlidingWindow) Do(f func(index int, value interface{})) {r.Each(f)}
+//removes up to n items from the front of the window, stopping at the first empty slot, and returns them in order
+func (r *SlidingWindow) RemoveFirstN(n int) []interface{} {
+	result := make([]interface{}, 0, n)
+	for len(result) < n {
+		value, ok := r.RemoveFirst()
+		if !ok {break}
+		result = append(result, value)
+	}
+	return result
+}
+//sets the items at base, base + 1, ... to values, stopping at the first one that cannot be set, and returns how many were set
+func (r *SlidingWindow) SetAll(base int, values []interface{}) int {
+	for i, value := range values {
+		if !r.Set(base + i, value) {return i}
+	}
+	return len(values)
+}
+//returns a new slice of the items in the window, in index order, skipping empty slots
+func (r *SlidingWindow) ToSlice() []interface{} {
+	result := make([]interface{}, 0, r.count)
+	r.Each(func(index int, value interface{}){result = append(result, value)})
+	return result
+}
+
+//A SyncSlidingWindow is a SlidingWindow protected by a mutex, so one goroutine can Set results while another removes them in order.
+//The Wait methods block, and are meant for a single producer and a single consumer
+type SyncSlidingWindow struct {
+	lock sync.Mutex
+	window *SlidingWindow
+	closed bool
+	added, removed chan bool
+}
+//creates a new SyncSlidingWindow around window, which should no longer be used directly
+func NewSyncSlidingWindow(window *SlidingWindow) *SyncSlidingWindow {
+	return &SyncSlidingWindow{window: window, added: make(chan bool, 1), removed: make(chan bool, 1)}
+}
+func signal(c chan bool) {
+	select {
+	case c <- true:
+	default:
+	}
+}
+//returns the current maximum available index
+func (r *SyncSlidingWindow) Max() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.window.Max()
+}
+//returns the number of items in the window
+func (r *SyncSlidingWindow) Count() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.window.Count()
+}
+//returns the item at index, if there is one, and also returns whether an item was there
+func (r *SyncSlidingWindow) Get(index int) (interface{}, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.window.Get(index)
+}
+//returns the first item, or nil if there is none, and also returns whether there was an item
+func (r *SyncSlidingWindow) GetFirst() (interface{}, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.window.GetFirst()
+}
+//sets the item at index to value, if the space is available, and also returns whether an item was set
+func (r *SyncSlidingWindow) Set(index int, value interface{}) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	ok := r.window.Set(index, value)
+	if ok {signal(r.added)}
+	return ok
+}
+//removes the first item, if there is one, and also returns whether an item was removed
+func (r *SyncSlidingWindow) RemoveFirst() (interface{}, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	value, ok := r.window.RemoveFirst()
+	if ok {signal(r.removed)}
+	return value, ok
+}
+//removes up to n items from the front of the window, stopping at the first empty slot, and returns them in order
+func (r *SyncSlidingWindow) RemoveFirstN(n int) []interface{} {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	result := r.window.RemoveFirstN(n)
+	if len(result) > 0 {signal(r.removed)}
+	return result
+}
+//sets the items at base, base + 1, ... to values, stopping at the first one that cannot be set, and returns how many were set
+func (r *SyncSlidingWindow) SetAll(base int, values []interface{}) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	count := r.window.SetAll(base, values)
+	if count > 0 {signal(r.added)}
+	return count
+}
+//sets the item at index to value, waiting for the window to slide far enough to hold it; returns false if index is already behind the window or the window is closed
+func (r *SyncSlidingWindow) SetWait(index int, value interface{}) bool {
+	for {
+		r.lock.Lock()
+		if r.closed || index < r.window.Base() {
+			r.lock.Unlock()
+			return false
+		}
+		if r.window.Set(index, value) {
+			r.lock.Unlock()
+			signal(r.added)
+			return true
+		}
+		r.lock.Unlock()
+		<- r.removed
+	}
+	return false
+}
+//removes the first item, waiting for it to be set; returns false once the window is closed and the first item is missing
+func (r *SyncSlidingWindow) RemoveFirstWait() (interface{}, bool) {
+	for {
+		r.lock.Lock()
+		value, ok := r.window.RemoveFirst()
+		closed := r.closed
+		r.lock.Unlock()
+		if ok {
+			signal(r.removed)
+			return value, true
+		}
+		if closed {return nil, false}
+		<- r.added
+	}
+	return nil, false
+}
+//marks the window closed, waking any waiters; items already set can still be removed
+func (r *SyncSlidingWindow) Close() {
+	r.lock.Lock()
+	r.closed = true
+	r.lock.Unlock()
+	signal(r.added)
+	signal(r.removed)
+}
@@ -0,0 +1,159 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "testing"
+import "time"
+
+func TestSlidingWindowSet(t *testing.T) {
+	r := NewSlidingWindow(2)
+	if r.Capacity() != 4 || r.Limit() != 4 || r.Max() != 3 {t.Fatalf("new window has capacity %d, limit %d, max %d", r.Capacity(), r.Limit(), r.Max())}
+	if !r.IsEmpty() {t.Error("new window is not empty")}
+	for i := 0; i < 4; i++ {
+		if !r.Set(i, i * 10) {t.Errorf("could not set %d", i)}
+	}
+	if !r.IsFull() || r.Count() != 4 {t.Errorf("full window has count %d", r.Count())}
+	if r.Set(4, 40) {t.Error("set past Max on a window that cannot grow")}
+	if r.Set(-1, -10) {t.Error("set before the base")}
+	if v, ok := r.Get(2); !ok || v != 20 {t.Errorf("got %v, %v at 2", v, ok)}
+	if v, ok := r.RemoveFirst(); !ok || v != 0 {t.Errorf("removed %v, %v", v, ok)}
+	if r.Base() != 1 || r.Max() != 4 {t.Errorf("after removing, base is %d and max is %d", r.Base(), r.Max())}
+	if !r.Set(4, 40) {t.Error("could not set the slot RemoveFirst freed")}
+	if r.Set(0, 0) {t.Error("set behind the window")}
+	if v, ok := r.Get(4); !ok || v != 40 {t.Errorf("got %v, %v at 4 after wrapping", v, ok)}
+}
+
+func TestSlidingWindowSetReplaces(t *testing.T) {
+	r := NewSlidingWindow(2)
+	r.Set(1, "a")
+	r.Set(1, "b")
+	if r.Count() != 1 {t.Errorf("setting a slot twice counted %d items", r.Count())}
+	if v, _ := r.Get(1); v != "b" {t.Errorf("got %v after replacing", v)}
+	if _, ok := r.RemoveFirst(); ok {t.Error("removed an empty first slot")}
+}
+
+func TestSlidingWindowGrow(t *testing.T) {
+	r := NewGrowableSlidingWindow(1, 3)
+	if r.Capacity() != 2 || r.Limit() != 8 {t.Fatalf("growable window has capacity %d and limit %d", r.Capacity(), r.Limit())}
+	r.Set(0, 0)
+	r.RemoveFirst()
+	r.Set(1, 1)
+	r.Set(2, 2)
+	if !r.Set(5, 5) {t.Fatal("could not grow to 5")}
+	if r.Capacity() != 8 {t.Errorf("grew to capacity %d", r.Capacity())}
+	for _, i := range []int{1, 2, 5} {
+		if v, ok := r.Get(i); !ok || v != i {t.Errorf("got %v, %v at %d after growing", v, ok, i)}
+	}
+	if r.Set(9, 9) {t.Error("grew past the limit")}
+	if !r.Set(8, 8) {t.Error("could not set the last slot within the limit")}
+}
+
+func TestSlidingWindowSetOrGrow(t *testing.T) {
+	r := NewSlidingWindow(1)
+	if !r.SetOrGrow(9, 9) {t.Fatal("SetOrGrow could not reach 9")}
+	if r.Capacity() != 16 || r.Limit() < 16 {t.Errorf("SetOrGrow left capacity %d and limit %d", r.Capacity(), r.Limit())}
+	if v, ok := r.Get(9); !ok || v != 9 {t.Errorf("got %v, %v at 9", v, ok)}
+	r.Set(0, 0)
+	r.RemoveFirst()
+	if r.SetOrGrow(0, 0) {t.Error("SetOrGrow set behind the window")}
+}
+
+func TestSlidingWindowEach(t *testing.T) {
+	r := NewSlidingWindow(2)
+	r.Set(0, "x")
+	r.RemoveFirst()
+	r.Set(4, "d")
+	r.Set(2, "b")
+	r.Set(1, "a")
+	indexes, values := []int{}, []interface{}{}
+	r.Each(func(index int, value interface{}){
+		indexes = append(indexes, index)
+		values = append(values, value)
+	})
+	if len(indexes) != 3 || indexes[0] != 1 || indexes[1] != 2 || indexes[2] != 4 {t.Errorf("Each visited %v", indexes)}
+	if len(values) != 3 || values[0] != "a" || values[1] != "b" || values[2] != "d" {t.Errorf("Each saw %v", values)}
+}
+
+func TestSlidingWindowToSlice(t *testing.T) {
+	r := NewSlidingWindow(3)
+	if len(r.ToSlice()) != 0 {t.Error("empty window has items")}
+	r.SetAll(2, []interface{}{2, 3})
+	r.Set(6, 6)
+	slice := r.ToSlice()
+	if len(slice) != 3 || slice[0] != 2 || slice[1] != 3 || slice[2] != 6 {t.Errorf("ToSlice returned %v", slice)}
+}
+
+func TestSlidingWindowRemoveFirstN(t *testing.T) {
+	r := NewSlidingWindow(2)
+	if set := r.SetAll(0, []interface{}{"a", "b", "c", "d", "e"}); set != 4 {t.Errorf("SetAll set %d items in a window of 4", set)}
+	if first := r.RemoveFirstN(2); len(first) != 2 || first[0] != "a" || first[1] != "b" {t.Errorf("RemoveFirstN removed %v", first)}
+	r.Set(5, "f")
+	if first := r.RemoveFirstN(4); len(first) != 2 || first[0] != "c" || first[1] != "d" {t.Errorf("RemoveFirstN removed %v up to an empty slot", first)}
+	if r.Base() != 4 {t.Errorf("base is %d after removing", r.Base())}
+	if first := r.RemoveFirstN(1); len(first) != 0 {t.Errorf("RemoveFirstN removed %v past an empty first slot", first)}
+}
+
+//returns a channel which receives true when f returns, so tests can check whether it blocks
+func finishes(f func()) chan bool {
+	done := make(chan bool, 1)
+	go func() {
+		f()
+		done <- true
+	}()
+	return done
+}
+
+//returns whether done receives within a tenth of a second
+func finishedSoon(done chan bool) bool {
+	select {
+	case <- done: return true
+	case <- time.After(1e8):
+	}
+	return false
+}
+
+func TestSyncSlidingWindowSetWait(t *testing.T) {
+	r := NewSyncSlidingWindow(NewSlidingWindow(1))
+	r.Set(0, "a")
+	r.Set(1, "b")
+	ok := false
+	done := finishes(func(){ok = r.SetWait(2, "c")})
+	if finishedSoon(done) {t.Fatal("SetWait did not wait for room in a full window")}
+	if v, _ := r.RemoveFirst(); v != "a" {t.Errorf("removed %v", v)}
+	if !finishedSoon(done) || !ok {t.Fatal("SetWait did not set once the window slid")}
+	if v, _ := r.Get(2); v != "c" {t.Errorf("got %v at 2", v)}
+	if r.SetWait(0, "x") {t.Error("SetWait set behind the window")}
+}
+
+func TestSyncSlidingWindowRemoveFirstWait(t *testing.T) {
+	r := NewSyncSlidingWindow(NewSlidingWindow(1))
+	var value interface{}
+	ok := false
+	done := finishes(func(){value, ok = r.RemoveFirstWait()})
+	if finishedSoon(done) {t.Fatal("RemoveFirstWait did not wait for the first item")}
+	r.Set(1, "b")
+	if finishedSoon(done) {t.Fatal("RemoveFirstWait took an item that is not first")}
+	r.Set(0, "a")
+	if !finishedSoon(done) || !ok || value != "a" {t.Fatalf("RemoveFirstWait returned %v, %v", value, ok)}
+	if value, ok = r.RemoveFirstWait(); !ok || value != "b" {t.Errorf("RemoveFirstWait returned %v, %v", value, ok)}
+}
+
+func TestSyncSlidingWindowClose(t *testing.T) {
+	r := NewSyncSlidingWindow(NewSlidingWindow(1))
+	r.Set(1, "b")
+	removed, set := true, true
+	removeDone := finishes(func(){_, removed = r.RemoveFirstWait()})
+	setDone := finishes(func(){set = r.SetWait(2, "c")})
+	if finishedSoon(removeDone) || finishedSoon(setDone) {t.Fatal("RemoveFirstWait and SetWait did not wait")}
+	r.Close()
+	if !finishedSoon(removeDone) || removed {t.Error("Close did not stop RemoveFirstWait waiting for a missing item")}
+	if !finishedSoon(setDone) || set {t.Error("Close did not stop SetWait waiting for room")}
+	r.Set(0, "a")
+	for _, want := range []string{"a", "b"} {
+		if value, ok := r.RemoveFirstWait(); !ok || value != want {t.Errorf("after closing, RemoveFirstWait returned %v, %v rather than %v", value, ok, want)}
+	}
+	if _, ok := r.RemoveFirstWait(); ok {t.Error("RemoveFirstWait removed from an empty closed window")}
+	if r.SetWait(3, "d") {t.Error("SetWait set in a closed window")}
+}
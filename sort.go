@@ -0,0 +1,77 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "sort"
+
+//a slice of elements, sortable with a less function
+type elSorter struct {
+	items []interface{}
+	less func(a, b El) bool
+}
+
+func (s elSorter) Len() int {return len(s.items)}
+func (s elSorter) Less(i, j int) bool {return s.less(s.items[i], s.items[j])}
+func (s elSorter) Swap(i, j int) {s.items[i], s.items[j] = s.items[j], s.items[i]}
+
+//merges the sorted slices a and b into dst, which must be exactly long enough, taking from a first when elements are equal
+func mergeInto(dst, a, b []interface{}, less func(a, b El) bool) {
+	i, j, k := 0, 0, 0
+	for ; i < len(a) && j < len(b); k++ {
+		if less(b[j], a[i]) {
+			dst[k] = b[j]
+			j++
+		} else {
+			dst[k] = a[i]
+			i++
+		}
+	}
+	k += copy(dst[k:], a[i:])
+	copy(dst[k:], b[j:])
+}
+
+//returns a new SequentialSeq of the elements of s sorted according to less, using partsOpt[0] goroutines, which defaults to 8: the elements are split into that many parts, which are sorted concurrently and then merged in pairs, also concurrently
+func (s Sequence) CSort(less func(a, b El) bool, partsOpt... int) Sequence {
+	parts := 8
+	if len(partsOpt) > 0 && partsOpt[0] > 0 {parts = partsOpt[0]}
+	items := append([]interface{}(nil), s.ToSlice()...)
+	if parts > len(items) {parts = len(items)}
+	if parts < 2 {
+		sort.Sort(elSorter{items, less})
+		return Sequence{(*SequentialSeq)(&items)}
+	}
+	//runs[i] is where run i starts; the last entry is the end of the items
+	runs := make([]int, parts + 1)
+	for i := range runs {runs[i] = i * len(items) / parts}
+	done := make(chan bool)
+	for i := 0; i < parts; i++ {
+		go func(part []interface{}) {
+			sort.Sort(elSorter{part, less})
+			done <- true
+		}(items[runs[i]:runs[i + 1]])
+	}
+	for i := 0; i < parts; i++ {<- done}
+	src, dst := items, make([]interface{}, len(items))
+	for len(runs) > 2 {
+		merged := []int{}
+		merges := 0
+		for i := 0; i + 1 < len(runs); i += 2 {
+			merged = append(merged, runs[i])
+			if i + 2 < len(runs) {
+				merges++
+				go func(lo, mid, hi int) {
+					mergeInto(dst[lo:hi], src[lo:mid], src[mid:hi], less)
+					done <- true
+				}(runs[i], runs[i + 1], runs[i + 2])
+			} else {
+				copy(dst[runs[i]:], src[runs[i]:runs[i + 1]])
+			}
+		}
+		for ; merges > 0; merges-- {<- done}
+		runs = append(merged, len(items))
+		src, dst = dst, src
+	}
+	return Sequence{(*SequentialSeq)(&src)}
+}
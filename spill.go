@@ -0,0 +1,226 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "bufio"
+import "io"
+import "io/ioutil"
+import "os"
+import "runtime"
+import "sort"
+
+//a temporary file of encoded elements, which is written once and then read any number of times
+type spillFile struct {
+	file *os.File
+	buf *bufio.Writer
+	enc Encoder
+	codec Codec
+	count int
+}
+
+//creates a new, empty spillFile
+func newSpillFile(codec Codec) (*spillFile, os.Error) {
+	file, err := ioutil.TempFile("", "seq-spill")
+	if err != nil {return nil, err}
+	buf := bufio.NewWriter(file)
+	return &spillFile{file, buf, codec.NewEncoder(buf), codec, 0}, nil
+}
+
+//appends el to the file
+func (f *spillFile) write(el El) os.Error {
+	f.count++
+	return encodeEl(f.enc, el)
+}
+
+//writes any buffered elements to the file, which must be done before reading them
+func (f *spillFile) flush() os.Error {return f.buf.Flush()}
+
+//returns a Decoder for the elements flushed so far, independent of any other readers
+func (f *spillFile) reader() Decoder {
+	return f.codec.NewDecoder(bufio.NewReader(io.NewSectionReader(f.file, 0, 1 << 62)))
+}
+
+//closes and deletes the file
+func (f *spillFile) remove() {
+	f.file.Close()
+	os.Remove(f.file.Name())
+}
+
+//returns a new ConcurrentSeq of the elements in the file, ending early if one cannot be decoded; the error is available from its Err method
+func (f *spillFile) sequence() Sequence {
+	return GenErr(func(c SeqChan) os.Error {
+		dec := f.reader()
+		for i := 0; i < f.count; i++ {
+			el, err := decodeEl(dec)
+			if err != nil {return err}
+			c <- el
+			if closed(c) {return nil}
+		}
+		return nil
+	})
+}
+
+//returns a new ConcurrentSeq of the elements of s sorted according to less, for sequences too large to sort in memory: runs of runSize elements are sorted in memory and written to temporary files with codecOpt[0], which defaults to GobCodec, and the runs are then merged as the result is read.  If s has no more than runSize elements, nothing is written.  The temporary files are deleted when the traversal finishes or is abandoned, and an error writing or reading them ends the sequence and is available from its Err method
+func (s Sequence) ExternalSort(less func(a, b El) bool, runSize int, codecOpt... Codec) Sequence {
+	codec := codecOf(codecOpt)
+	if runSize < 1 {runSize = 1}
+	return GenErr(func(c SeqChan) os.Error {
+		runs := []*spillFile{}
+		defer func() {
+			for _, run := range runs {run.remove()}
+		}()
+		buf := make([]interface{}, 0, runSize)
+		var err os.Error
+		spill := func() {
+			run, serr := newSpillFile(codec)
+			if serr != nil {
+				err = serr
+				return
+			}
+			runs = append(runs, run)
+			sort.Sort(elSorter{buf, less})
+			for _, el := range buf {
+				if err = run.write(el); err != nil {return}
+			}
+			err = run.flush()
+			buf = buf[:0]
+		}
+		s.While(func(el El)bool{
+			buf = append(buf, el)
+			if len(buf) == runSize {spill()}
+			return err == nil
+		})
+		if err != nil {return err}
+		if len(runs) == 0 {
+			sort.Sort(elSorter{buf, less})
+			for _, el := range buf {
+				c <- el
+				if closed(c) {return nil}
+			}
+			return nil
+		}
+		if len(buf) > 0 {
+			if spill(); err != nil {return err}
+		}
+		sources := make([]Sequence, len(runs))
+		for i, run := range runs {sources[i] = run.sequence()}
+		aborted := false
+		MergeBy(less, sources...).Find(func(el El)bool{
+			c <- el
+			aborted = closed(c)
+			return aborted
+		})
+		if aborted {return nil}
+		for _, source := range sources {
+			if err := source.Err(); err != nil {return err}
+		}
+		return nil
+	}).stage("ExternalSort", s, "runSize", runSize)
+}
+
+//A SpillSeq is a SequentialSeq too large to keep in memory: it holds its first elements in memory and the rest in a temporary file, which each traversal reads back.
+//A failure reading the file ends the traversal early and is available from Err.
+//Close deletes the file; otherwise it is deleted when the SpillSeq and every Rest of it are garbage collected
+type SpillSeq struct {
+	memory []interface{}
+	file *spillFile
+	skip int
+	holder *errHolder
+}
+
+//returns a new SpillSeq of the elements of s, keeping the first inMemory of them in memory and writing the rest to a temporary file with codecOpt[0], which defaults to GobCodec.  This is an alternative to Sequential for concurrent sequences that may not fit in memory.  It returns an error, along with an empty sequence, if the file cannot be written
+func (s Sequence) Spill(inMemory int, codecOpt... Codec) (Sequence, os.Error) {
+	codec := codecOf(codecOpt)
+	if inMemory < 0 {inMemory = 0}
+	//a hint covers the whole of s, but only inMemory of its elements are kept
+	capacity := s.quickLen(8)
+	if capacity > inMemory {capacity = inMemory}
+	memory := make([]interface{}, 0, capacity)
+	var file *spillFile
+	var err os.Error
+	s.While(func(el El)bool{
+		if len(memory) < inMemory {
+			memory = append(memory, el)
+			return true
+		}
+		if file == nil {
+			if file, err = newSpillFile(codec); err != nil {return false}
+			runtime.SetFinalizer(file, (*spillFile).remove)
+		}
+		err = file.write(el)
+		return err == nil
+	})
+	if err == nil && file != nil {err = file.flush()}
+	if err != nil {
+		if file != nil {file.remove()}
+		return From(), err
+	}
+	return Sequence{&SpillSeq{memory, file, 0, &errHolder{}}}, nil
+}
+
+//SpillSeqs are not concurrent; return false
+func (s *SpillSeq) IsConcurrent() bool {return false}
+
+//returns the first item in a sequence for which f returns true or nil if none is found
+func (s *SpillSeq) Find(f func(el El)bool) El {
+	s.holder.set(nil)
+	for i := s.skip; i < len(s.memory); i++ {
+		if f(s.memory[i]) {return s.memory[i]}
+	}
+	if s.file == nil {return nil}
+	dec := s.file.reader()
+	for i := len(s.memory); i < len(s.memory) + s.file.count; i++ {
+		el, err := decodeEl(dec)
+		if err != nil {
+			s.holder.set(err)
+			return nil
+		}
+		if i >= s.skip && f(el) {return el}
+	}
+	return nil
+}
+
+//returns a new SpillSeq consisting of all of the elements of s except for the first one, sharing s's file
+func (s *SpillSeq) Rest() Sequence {return Sequence{&SpillSeq{s.memory, s.file, s.skip + 1, s.holder}}}
+
+//returns the length of s
+func (s *SpillSeq) Len() int {
+	count := len(s.memory) - s.skip
+	if s.file != nil {count += s.file.count}
+	if count < 0 {return 0}
+	return count
+}
+
+//returns the error that ended the most recent traversal of s, or nil if it finished normally
+func (s *SpillSeq) Err() os.Error {return s.holder.get()}
+
+//deletes s's file, after which the elements that were in it are missing from s and every Rest of it
+func (s *SpillSeq) Close() {
+	if s.file == nil {return}
+	runtime.SetFinalizer(s.file, nil)
+	s.file.remove()
+	s.file.count = 0
+}
+
+func (s *SpillSeq) Describe() Description {
+	return Description{"SpillSeq", map[string]interface{}{"inMemory": len(s.memory), "len": s.Len()}, nil}
+}
+
+//returns a sequence with the elements of s that can be traversed any number of times without running s again.  If s is concurrent, it is traversed once, now, and its elements are kept in memory or, if inMemoryOpt is given, in a SpillSeq which keeps only the first inMemoryOpt[0] in memory; otherwise s is returned.  If s's traversal fails, the result has the elements that arrived and Err returns the failure; if the SpillSeq cannot be written, the result is empty and Err returns that error
+func (s Sequence) Replayable(inMemoryOpt... int) Sequence {
+	if !s.IsConcurrent() {return s}
+	var result Sequence
+	var err os.Error
+	if len(inMemoryOpt) > 0 {
+		result, err = s.Spill(inMemoryOpt[0])
+	} else {
+		result = s.Sequential()
+	}
+	if err == nil {err = s.Err()}
+	if err == nil {return result}
+	holder := &errHolder{}
+	holder.set(err)
+	return Sequence{&errSeq{result.Seq, holder}}
+}
@@ -0,0 +1,17 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "testing"
+
+func TestSpillBoundsMemoryByInMemory(t *testing.T) {
+	spilled, err := SUpto(1000).WithLenHint(1 << 24, false).Spill(10)
+	if err != nil {t.Fatal(err)}
+	defer spilled.Close()
+	spill := spilled.Seq.(*SpillSeq)
+	if len(spill.memory) != 10 || cap(spill.memory) > 10 {t.Errorf("kept %d elements in memory with capacity %d", len(spill.memory), cap(spill.memory))}
+	if spilled.Len() != 1000 {t.Errorf("spilled %d elements", spilled.Len())}
+	if last := spilled.ToSlice()[999]; last != 999 {t.Errorf("last element is %v", last)}
+}
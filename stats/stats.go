@@ -0,0 +1,84 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+//The stats package provides summary statistics over sequences of numbers.  The elements can be any mix of ints, uints, and floats;
+//other elements are reported according to the sequence's Policy.  Each function returns whether the sequence had enough elements
+package stats
+
+import "fmt"
+import "math"
+import "sort"
+import . "github.com/zot/seq"
+
+//returns the arithmetic mean of the numbers in s
+func Mean(s Sequence) (float64, bool) {return s.Average()}
+
+func mean(values []float64) (float64, bool) {
+	if len(values) == 0 {return 0, false}
+	sum := 0.0
+	for _, v := range values {sum += v}
+	return sum / float64(len(values)), true
+}
+
+//returns the sample variance of the numbers in s, which needs at least two of them
+func Variance(s Sequence) (float64, bool) {return variance(s.Float64s())}
+
+func variance(values []float64) (float64, bool) {
+	if len(values) < 2 {return 0, false}
+	m, _ := mean(values)
+	sum := 0.0
+	for _, v := range values {sum += (v - m) * (v - m)}
+	return sum / float64(len(values) - 1), true
+}
+
+//returns the sample standard deviation of the numbers in s, which needs at least two of them
+func StdDev(s Sequence) (float64, bool) {
+	v, ok := Variance(s)
+	return math.Sqrt(v), ok
+}
+
+//returns the median of the numbers in s
+func Median(s Sequence) (float64, bool) {return Percentile(s, 50)}
+
+//returns the pth percentile of the numbers in s, for p from 0 to 100, interpolating between the closest ranks
+func Percentile(s Sequence, p float64) (float64, bool) {
+	values := s.Float64s()
+	sort.SortFloat64s(values)
+	return percentile(values, p)
+}
+
+//returns the pth percentile of sorted
+func percentile(sorted []float64, p float64) (float64, bool) {
+	if len(sorted) == 0 || p < 0 || p > 100 {return 0, false}
+	rank := p / 100 * float64(len(sorted) - 1)
+	lower := int(rank)
+	if lower + 1 >= len(sorted) {return sorted[len(sorted) - 1], true}
+	frac := rank - float64(lower)
+	return sorted[lower] + frac * (sorted[lower + 1] - sorted[lower]), true
+}
+
+//summary statistics of a sequence of numbers; StdDev is 0 when there are fewer than two
+type Stats struct {
+	Count int
+	Min, Max, Mean, StdDev float64
+	P25, Median, P75 float64
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("n=%d min=%g p25=%g median=%g p75=%g max=%g mean=%g stddev=%g", s.Count, s.Min, s.P25, s.Median, s.P75, s.Max, s.Mean, s.StdDev)
+}
+
+//returns summary statistics of the numbers in s, traversing s only once
+func Summary(s Sequence) (Stats, bool) {
+	values := s.Float64s()
+	if len(values) == 0 {return Stats{}, false}
+	sort.SortFloat64s(values)
+	result := Stats{Count: len(values), Min: values[0], Max: values[len(values) - 1]}
+	result.Mean, _ = mean(values)
+	if v, ok := variance(values); ok {result.StdDev = math.Sqrt(v)}
+	result.P25, _ = percentile(values, 25)
+	result.Median, _ = percentile(values, 50)
+	result.P75, _ = percentile(values, 75)
+	return result, true
+}
@@ -0,0 +1,57 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "fmt"
+import "io"
+import "os"
+
+//writes s, a sequence of row sequences, to w as a text table with aligned columns, preceded by headers and a rule if there are any.  Numbers are right-aligned and everything else is left-aligned; short rows are padded with empty cells
+func (s Sequence) PrettyTable(w io.Writer, headers... string) os.Error {
+	rows := [][]string{}
+	numeric := []bool{}
+	widths := make([]int, len(headers))
+	for i, h := range headers {widths[i] = len(h)}
+	s.Do(func(row El){
+		cells := []string{}
+		row.(Sequence).Do(func(cell El){
+			col := len(cells)
+			cells = append(cells, fmt.Sprint(cell))
+			if col >= len(widths) {widths = append(widths, 0)}
+			if col >= len(numeric) {numeric = append(numeric, true)}
+			if len(cells[col]) > widths[col] {widths[col] = len(cells[col])}
+			if _, isNum := ToFloat64(cell); !isNum {numeric[col] = false}
+		})
+		rows = append(rows, cells)
+	})
+	for len(numeric) < len(widths) {numeric = append(numeric, false)}
+	writeRow := func(cells []string) os.Error {
+		for col, width := range widths {
+			cell := ""
+			if col < len(cells) {cell = cells[col]}
+			sep := "  "
+			if col == 0 {sep = ""}
+			format := "%s%-*s"
+			if numeric[col] {format = "%s%*s"}
+			if _, err := fmt.Fprintf(w, format, sep, width, cell); err != nil {return err}
+		}
+		_, err := fmt.Fprintln(w)
+		return err
+	}
+	if len(headers) > 0 {
+		if err := writeRow(headers); err != nil {return err}
+		rule := make([]string, len(widths))
+		for col, width := range widths {
+			dashes := make([]byte, width)
+			for i := range dashes {dashes[i] = '-'}
+			rule[col] = string(dashes)
+		}
+		if err := writeRow(rule); err != nil {return err}
+	}
+	for _, cells := range rows {
+		if err := writeRow(cells); err != nil {return err}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "bytes"
+import "os"
+import "utf8"
+
+//returns a new SequentialSeq of the runes (ints) in s, decoding UTF-8; invalid encodings become utf8.RuneError, as they do in a range loop
+func FromString(s string) Sequence {
+	runes := make([]interface{}, 0, utf8.RuneCountInString(s))
+	for _, r := range s {runes = append(runes, r)}
+	return Sequence{(*SequentialSeq)(&runes)}
+}
+
+//returns a new SequentialSeq of [offset, rune] pairs for the runes in s, where offset is the byte index of the rune in s, so positions survive filtering and splitting
+func FromStringIndexed(s string) Sequence {
+	pairs := make([]interface{}, 0, utf8.RuneCountInString(s))
+	for i, r := range s {pairs = append(pairs, From(i, r))}
+	return Sequence{(*SequentialSeq)(&pairs)}
+}
+
+//returns a new SequentialSeq of the runes (ints) in the UTF-8 text b; invalid encodings become utf8.RuneError, one per bad byte
+func FromBytes(b []byte) Sequence {
+	runes := make([]interface{}, 0, utf8.RuneCount(b))
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		runes = append(runes, r)
+		b = b[size:]
+	}
+	return Sequence{(*SequentialSeq)(&runes)}
+}
+
+//returns a new sequence of the same type as s holding the SequentialSeq pieces of s between elements for which isSep returns true, like strings.Split: n separators make n + 1 pieces, some of which may be empty, and an empty s makes none.  Separators are dropped unless keepSepOpt[0] is true, in which case each one ends the piece before it, like strings.SplitAfter
+func (s Sequence) SplitBy(isSep func(el El) bool, keepSepOpt... bool) Sequence {
+	keepSep := len(keepSepOpt) > 0 && keepSepOpt[0]
+	return s.produce("SplitBy", func(emit func(el El)){
+		var piece []interface{}
+		s.Do(func(el El){
+			if piece == nil {piece = make([]interface{}, 0, 8)}
+			if !isSep(el) {
+				piece = append(piece, el)
+				return
+			}
+			if keepSep {piece = append(piece, el)}
+			emit(pieceSeq(piece))
+			piece = make([]interface{}, 0, 8)
+		})
+		if piece != nil {emit(pieceSeq(piece))}
+	})
+}
+
+//returns a SequentialSeq of piece that does not change when the caller's variable does
+func pieceSeq(piece []interface{}) Sequence {return Sequence{(*SequentialSeq)(&piece)}}
+
+//returns the string elements of s concatenated with sep between them, built in one buffer rather than by repeated concatenation, or a *SeqError if an element is not a string
+func (s Sequence) Join(sep string) (string, os.Error) {
+	buf := bytes.NewBuffer(make([]byte, 0, 64))
+	index := 0
+	var err os.Error
+	s.While(func(el El)bool{
+		str, isString := el.(string)
+		if !isString {
+			err = seqError("Join", "element %d is a %T, not a string", index, el)
+			return false
+		}
+		if index > 0 {buf.WriteString(sep)}
+		buf.WriteString(str)
+		index++
+		return true
+	})
+	if err != nil {return "", err}
+	return buf.String(), nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+//receives the elements a Transform produces; returning false stops the traversal
+type Step func(el El) bool
+
+//a reusable transformation, independent of any source, which wraps the Step receiving its output in a Step receiving its input.  Transforms run every stage for each element in a single pass, without intermediate sequences.  A Transform is called once per traversal, so stateful transforms like Taking keep their state in the Step they return
+type Transform func(next Step) Step
+
+//returns a Transform which applies f to each element
+func Mapping(f func(el El) El) Transform {
+	return func(next Step) Step {
+		return func(el El) bool {return next(f(el))}
+	}
+}
+
+//returns a Transform which keeps the elements for which filter returns true
+func Filtering(filter func(el El) bool) Transform {
+	return func(next Step) Step {
+		return func(el El) bool {return !filter(el) || next(el)}
+	}
+}
+
+//returns a Transform which keeps the first n elements and then stops
+func Taking(n int) Transform {
+	return func(next Step) Step {
+		count := 0
+		return func(el El) bool {
+			if count >= n {return false}
+			count++
+			return next(el) && count < n
+		}
+	}
+}
+
+//returns a Transform which applies each of transforms in turn, first to last
+func Compose(transforms... Transform) Transform {
+	return func(next Step) Step {
+		for i := len(transforms) - 1; i >= 0; i-- {next = transforms[i](next)}
+		return next
+	}
+}
+
+//feeds the elements of s through t into emit, stopping early if the transform does
+func (s Sequence) transform(t Transform, emit func(el El)) {
+	step := t(func(el El) bool {
+		emit(el)
+		return true
+	})
+	s.Find(func(el El)bool{return !step(el)})
+}
+
+//returns a new sequence of the same type as s consisting of the output of t applied to the elements of s
+func (s Sequence) Transform(t Transform) Sequence {
+	return s.produce("Transform", func(emit func(el El)){s.transform(t, emit)})
+}
+
+//returns a new channel carrying the output of t applied to the elements read from input; if the transform stops early or the consumer closes the channel, input is closed to abandon the rest
+func (t Transform) Chan(input SeqChan) SeqChan {
+	output := make(SeqChan)
+	go func() {
+		defer close(output)
+		step := t(func(el El) bool {
+			output <- el
+			return !closed(output)
+		})
+		for el := <- input; !closed(input); el = <- input {
+			if !step(el) {
+				close(input)
+				return
+			}
+		}
+	}()
+	return output
+}
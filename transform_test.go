@@ -0,0 +1,29 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "testing"
+import "time"
+
+func TestTransformChanClosesInputWhenOutputCloses(t *testing.T) {
+	input := make(SeqChan)
+	abandoned := make(chan bool)
+	go func() {
+		for i := 0; ; i++ {
+			input <- i
+			if closed(input) {
+				abandoned <- true
+				return
+			}
+		}
+	}()
+	output := Mapping(func(el El) El {return el.(int) * 2}).Chan(input)
+	if el := <- output; el != 0 {t.Errorf("first element is %v", el)}
+	close(output)
+	select {
+	case <- abandoned:
+	case <- time.After(1e9): t.Error("input was not closed after the output was")
+	}
+}
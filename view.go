@@ -0,0 +1,79 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+//a view of the results of applying f to the elements of source, computed during each traversal
+type mapSeq struct {
+	source Sequence
+	f func(el El) El
+}
+
+//returns a new sequence of the results of applying f to the elements of s, like Map, but as a view that applies f during each traversal instead of building a slice, so traversals that stop early, like First, only apply f to the elements they reach.  f is applied again on every traversal.  A concurrent s is already computed as it is read, so for it this is CMap
+func (s Sequence) LazyMap(f func(el El) El) Sequence {
+	if s.IsConcurrent() {return s.CMap(f)}
+	return Sequence{&mapSeq{s, f}}.stage("LazyMap", s)
+}
+
+//mapSeqs are not concurrent; return false
+func (s *mapSeq) IsConcurrent() bool {return false}
+
+//returns the first result for which f returns true or nil if none is found
+func (s *mapSeq) Find(f func(el El)bool) El {
+	var result El
+	found := false
+	s.source.Find(func(el El)bool{
+		result = s.f(el)
+		found = f(result)
+		return found
+	})
+	if !found {return nil}
+	return result
+}
+
+//returns a new mapSeq of the rest of s's source
+func (s *mapSeq) Rest() Sequence {return Sequence{&mapSeq{s.source.Rest(), s.f}}}
+
+//returns the length of s, which is the length of its source
+func (s *mapSeq) Len() int {return s.source.Len()}
+
+//a view of the elements of source for which filter returns true, chosen during each traversal
+type filterSeq struct {
+	source Sequence
+	filter func(el El) bool
+}
+
+//returns a new sequence of the elements of s for which filter returns true, like Filter, but as a view that tests the elements during each traversal instead of building a slice, so s.LazyFilter(p).First() stops at the first match.  A concurrent s is already filtered as it is read, so for it this is CFilter
+func (s Sequence) LazyFilter(filter func(el El) bool) Sequence {
+	if s.IsConcurrent() {return s.CFilter(filter)}
+	return Sequence{&filterSeq{s, filter}}.stage("LazyFilter", s)
+}
+
+//filterSeqs are not concurrent; return false
+func (s *filterSeq) IsConcurrent() bool {return false}
+
+//returns the first element that passes the filter for which f returns true or nil if none is found
+func (s *filterSeq) Find(f func(el El)bool) El {
+	found := false
+	result := s.source.Find(func(el El)bool{
+		found = s.filter(el) && f(el)
+		return found
+	})
+	if !found {return nil}
+	return result
+}
+
+//returns a new filterSeq of the elements of s's source after the first one that passes the filter
+func (s *filterSeq) Rest() Sequence {
+	rest := s.source
+	for !rest.IsEmpty() {
+		head := rest.First()
+		rest = rest.Rest()
+		if s.filter(head) {break}
+	}
+	return Sequence{&filterSeq{rest, s.filter}}
+}
+
+//returns the number of elements that pass the filter
+func (s *filterSeq) Len() int {return s.source.Count(s.filter)}
@@ -0,0 +1,313 @@
+// Copyright 2010 Bill Burdick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+package seq
+
+import "fmt"
+import "sort"
+
+//the events for one key that arrived without a gap longer than the session gap
+type session struct {
+	key interface{}
+	events []interface{}
+	start, last int64
+}
+
+//returns the session as a [key, [event, ...]] sequence, which later additions to the session do not change
+func (s *session) toSeq() Sequence {
+	events := s.events
+	return From(s.key, Sequence{(*SequentialSeq)(&events)})
+}
+
+type sessionsByStart []*session
+
+func (s sessionsByStart) Len() int {return len(s)}
+func (s sessionsByStart) Less(i, j int) bool {return s[i].start < s[j].start}
+func (s sessionsByStart) Swap(i, j int) {s[i], s[j] = s[j], s[i]}
+
+//an element with its event time, in nanoseconds; WithWatermarks sets Late when Time was already behind the watermark when the element arrived
+type Event struct {
+	Value El
+	Time int64
+	Late bool
+}
+
+//a promise that no more events with times before it are expected
+type Watermark int64
+
+//how windowing operators treat events that belong in windows they have already emitted
+type LatePolicy int
+
+const (
+	//discard late events
+	DropLate LatePolicy = iota
+	//emit each late event in a window of its own
+	EmitLate
+	//add late events to the window they belong in and emit that window again, until the watermark passes the window's end by another window's length (another gap, for sessions); after that they are emitted like EmitLate's
+	UpdateLate
+)
+
+func (p LatePolicy) String() string {
+	switch p {
+	case DropLate: return "DropLate"
+	case EmitLate: return "EmitLate"
+	case UpdateLate: return "UpdateLate"
+	}
+	return fmt.Sprintf("LatePolicy(%d)", int(p))
+}
+
+//returns a new ConcurrentSeq which wraps each element of s in an Event, timestamped with extract, and follows them with a Watermark whenever the largest time seen so far, less maxLateness, advances.  Windowing operators use the watermarks to decide when event-time windows are complete, so maxLateness is how far out of order the events can arrive without being late
+func (s Sequence) WithWatermarks(extract func(el El) int64, maxLateness int64) Sequence {
+	return Gen(func(c SeqChan){
+		var max, mark int64
+		started, marked := false, false
+		s.Do(func(el El){
+			t := extract(el)
+			c <- Event{el, t, marked && t < mark}
+			if !started || t > max {
+				max = t
+				started = true
+			}
+			if !marked || max - maxLateness > mark {
+				mark = max - maxLateness
+				marked = true
+				c <- Watermark(mark)
+			}
+		})
+	}).stage("WithWatermarks", s, "maxLateness", maxLateness)
+}
+
+//returns a new ConcurrentSeq of sessions, grouping the elements of s by key and closing a key's session when no element with that key arrives for gap nanoseconds of s's Clock.  Each session is a sequence of the key and the sequence of its elements, in arrival order.  Sessions are emitted as they close, oldest first, and all open sessions are emitted when s ends.
+//
+//If s comes from WithWatermarks, sessions are measured in event time instead of arrival time: key is applied to each Event's Value, a session closes when the watermark passes its last event plus gap, and events whose sessions have already closed are handled according to latePolicyOpt, which defaults to DropLate
+func (s Sequence) SessionWindows(gap int64, key func(el El) interface{}, latePolicyOpt... LatePolicy) Sequence {
+	latePolicy := DropLate
+	if len(latePolicyOpt) > 0 {latePolicy = latePolicyOpt[0]}
+	clock := s.Clock()
+	return Gen(func(c SeqChan){
+		input := s.channel()
+		tick := clock.After(gap)
+		sessions := map[interface{}]*session{}
+		fired := map[interface{}]*session{}
+		eventTime, marked := false, false
+		var mark int64
+		emit := func(all bool, limit int64) {
+			//a closed session takes late updates until the watermark passes its close by another gap
+			for k, prev := range fired {
+				if prev.last + 2 * gap <= limit {fired[k] = nil, false}
+			}
+			done := sessionsByStart{}
+			for k, sess := range sessions {
+				if all || sess.last + gap <= limit {
+					done = append(done, sess)
+					sessions[k] = nil, false
+					if latePolicy == UpdateLate && eventTime {fired[k] = sess}
+				}
+			}
+			sort.Sort(done)
+			for _, sess := range done {c <- sess.toSeq()}
+		}
+		add := func(value El, t int64) {
+			k := key(value)
+			sess := sessions[k]
+			if sess != nil && (t - sess.last >= gap || sess.start - t >= gap) {
+				c <- sess.toSeq()
+				sess = nil
+			}
+			if sess == nil {
+				if eventTime && marked && t + gap <= mark {
+					switch latePolicy {
+					case EmitLate: c <- From(k, From(value))
+					case UpdateLate:
+						if prev := fired[k]; prev != nil && t - prev.last < gap && prev.start - t < gap {
+							prev.events = append(prev.events, value)
+							if t < prev.start {prev.start = t}
+							if t > prev.last {prev.last = t}
+							c <- prev.toSeq()
+						} else {
+							c <- From(k, From(value))
+						}
+					}
+					return
+				}
+				sess = &session{k, make([]interface{}, 0, 8), t, t}
+				sessions[k] = sess
+			}
+			sess.events = append(sess.events, value)
+			if t < sess.start {sess.start = t}
+			if t > sess.last {sess.last = t}
+		}
+		for {
+			select {
+			case el := <- input:
+				if closed(input) {
+					emit(true, 0)
+					return
+				}
+				switch e := el.(type) {
+				case Watermark:
+					eventTime, marked, mark = true, true, int64(e)
+					emit(false, mark)
+				case Event:
+					eventTime = true
+					add(e.Value, e.Time)
+				default:
+					add(el, clock.Now())
+				}
+			case now := <- tick:
+				if !eventTime {emit(false, now)}
+				tick = clock.After(gap)
+			}
+		}
+	}).stage("SessionWindows", s, "gap", gap, "latePolicy", latePolicy).WithClock(clock)
+}
+
+//returns a over b rounded down, even when a is negative
+func floorDiv(a, b int64) int64 {
+	if a < 0 {return -((-a + b - 1) / b)}
+	return a / b
+}
+
+//returns a new ConcurrentSeq of the event-time windows of s that assign chooses, as [start, [element, ...]] sequences in order of start.  Elements of s are timestamped with timestamp, except that Events from WithWatermarks use their own Time and Value.  A window [start, start + size) is emitted once the watermark reaches its end: the watermarks from WithWatermarks if s has them, or else the largest timestamp seen so far.  Elements that belong in windows that were already emitted are handled according to latePolicy, and the remaining windows are emitted when s ends
+func (s Sequence) timeWindows(stage string, timestamp func(el El) int64, size int64, assign func(t int64) []int64, latePolicy LatePolicy) Sequence {
+	return Gen(func(c SeqChan){
+		windows := map[int64]*session{}
+		fired := map[int64]*session{}
+		var mark int64
+		marked, explicit := false, false
+		flush := func(all bool) {
+			//an emitted window takes late updates until the watermark passes its end by another size
+			for start := range fired {
+				if start + 2 * size <= mark {fired[start] = nil, false}
+			}
+			done := sessionsByStart{}
+			for start, w := range windows {
+				if all || start + size <= mark {
+					done = append(done, w)
+					windows[start] = nil, false
+					if latePolicy == UpdateLate {fired[start] = w}
+				}
+			}
+			sort.Sort(done)
+			for _, w := range done {c <- w.toSeq()}
+		}
+		add := func(value El, t int64) {
+			for _, start := range assign(t) {
+				w := windows[start]
+				if w == nil && marked && start + size <= mark {
+					switch latePolicy {
+					case EmitLate: c <- From(start, From(value))
+					case UpdateLate:
+						if prev := fired[start]; prev != nil {
+							prev.events = append(prev.events, value)
+							c <- prev.toSeq()
+						} else {
+							c <- From(start, From(value))
+						}
+					}
+					continue
+				}
+				if w == nil {
+					w = &session{start, make([]interface{}, 0, 8), start, start}
+					windows[start] = w
+				}
+				w.events = append(w.events, value)
+			}
+		}
+		s.Do(func(el El){
+			switch e := el.(type) {
+			case Watermark:
+				mark, marked, explicit = int64(e), true, true
+				flush(false)
+			case Event: add(e.Value, e.Time)
+			default:
+				t := timestamp(el)
+				add(el, t)
+				if !explicit && (!marked || t > mark) {
+					mark, marked = t, true
+					flush(false)
+				}
+			}
+		})
+		flush(true)
+	}).stage(stage, s, "size", size, "latePolicy", latePolicy)
+}
+
+//returns latePolicyOpt[0], or DropLate if it is not given
+func latePolicyOf(latePolicyOpt []LatePolicy) LatePolicy {
+	if len(latePolicyOpt) > 0 {return latePolicyOpt[0]}
+	return DropLate
+}
+
+//returns a new ConcurrentSeq grouping the elements of s into consecutive, non-overlapping windows of size nanoseconds of event time, starting at multiples of size, as [start, [element, ...]] sequences.  Event times come from timestamp, or from Events if s comes from WithWatermarks; see timeWindows for when windows are emitted.  Late elements are handled according to latePolicyOpt, which defaults to DropLate.  A size less than 1 is misuse, reported according to s's Policy
+func (s Sequence) TumblingWindow(size int64, timestamp func(el El) int64, latePolicyOpt... LatePolicy) Sequence {
+	if size < 1 {
+		s.check(seqError("TumblingWindow", "window size %d is less than 1", size))
+		return Gen(func(c SeqChan){})
+	}
+	return s.timeWindows("TumblingWindow", timestamp, size, func(t int64) []int64 {
+		return []int64{floorDiv(t, size) * size}
+	}, latePolicyOf(latePolicyOpt))
+}
+
+//returns a new ConcurrentSeq grouping the elements of s into windows of size nanoseconds of event time which start every slide nanoseconds, as [start, [element, ...]] sequences, so an element belongs to every window that covers its time.  Otherwise it works like TumblingWindow
+func (s Sequence) SlidingWindowBy(size, slide int64, timestamp func(el El) int64, latePolicyOpt... LatePolicy) Sequence {
+	if size < 1 || slide < 1 {
+		s.check(seqError("SlidingWindowBy", "window size %d or slide %d is less than 1", size, slide))
+		return Gen(func(c SeqChan){})
+	}
+	return s.timeWindows("SlidingWindowBy", timestamp, size, func(t int64) []int64 {
+		starts := []int64{}
+		for start := floorDiv(t, slide) * slide; start > t - size; start -= slide {starts = append([]int64{start}, starts...)}
+		return starts
+	}, latePolicyOf(latePolicyOpt))
+}
+
+//returns a new ConcurrentSeq grouping the elements of s into sessions of event time, as [start, [element, ...]] sequences: a session ends when gap nanoseconds of event time pass without an element.  This is SessionWindows with a single key and timestamp as the event time, allowing events to arrive up to gap out of order
+func (s Sequence) SessionWindow(gap int64, timestamp func(el El) int64, latePolicyOpt... LatePolicy) Sequence {
+	return s.WithWatermarks(timestamp, gap).SessionWindows(gap, func(el El) interface{} {return nil}, latePolicyOpt...).Map(func(el El) El {
+		_, events := el.(Sequence).First2()
+		start, _ := events.(Sequence).Map(func(el El) El {return timestamp(el)}).Min(func(a, b El) bool {return a.(int64) < b.(int64)})
+		return From(start, events)
+	}).stage("SessionWindow", s, "gap", gap)
+}
+
+//returns a new ConcurrentSeq of batches of consecutive elements of s, as SequentialSeqs, for feeding bulk APIs from a live stream: a batch is emitted when it reaches maxCount elements or when maxWait nanoseconds of s's Clock have passed since its first element arrived, whichever comes first, and any partial batch is emitted when s ends.  A maxWait less than 1 batches by count alone.  A maxCount less than 1 is misuse, reported according to s's Policy
+func (s Sequence) BatchBy(maxCount int, maxWait int64) Sequence {
+	if maxCount < 1 {
+		s.check(seqError("BatchBy", "batch size %d is less than 1", maxCount))
+		return Gen(func(c SeqChan){})
+	}
+	clock := s.Clock()
+	return Gen(func(c SeqChan){
+		input := s.channel()
+		defer close(input)
+		var batch []interface{}
+		var timer <-chan int64
+		flush := func() {
+			if len(batch) > 0 {
+				full := batch
+				c <- Sequence{(*SequentialSeq)(&full)}
+			}
+			batch, timer = nil, nil
+		}
+		for {
+			select {
+			case el := <- input:
+				if closed(input) {
+					flush()
+					return
+				}
+				if len(batch) == 0 {
+					batch = make([]interface{}, 0, maxCount)
+					if maxWait > 0 {timer = clock.After(maxWait)}
+				}
+				batch = append(batch, el)
+				if len(batch) == maxCount {flush()}
+			case <- timer: flush()
+			}
+			if closed(c) {return}
+		}
+	}).stage("BatchBy", s, "maxCount", maxCount, "maxWait", maxWait).WithClock(clock)
+}